@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"task-board/lexorank"
 	"task-board/models"
 )
 
@@ -34,15 +36,21 @@ func GetConfigFromEnv() Config {
 	}
 }
 
+// DSN формирует строку подключения к PostgreSQL
+func (c Config) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		c.Host, c.User, c.Password, c.DBName, c.Port, c.SSLMode)
+}
+
 // Connect подключается к базе данных PostgreSQL через GORM
 func Connect() error {
-	config := GetConfigFromEnv()
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		config.Host, config.User, config.Password, config.DBName, config.Port, config.SSLMode)
+	dsn := GetConfigFromEnv().DSN()
 
 	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// Ошибки уникальности приходят как gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		return fmt.Errorf("ошибка подключения к базе данных: %w", err)
 	}
@@ -76,15 +84,176 @@ func Close() error {
 
 // MigrateModels выполняет автомиграцию моделей
 func MigrateModels() error {
-	err := DB.AutoMigrate(&models.Board{}, &models.Column{}, &models.Card{})
+	// Переводим старые целочисленные order_num на строковые ранги до автомиграции,
+	// иначе уникальные индексы по рангу не создадутся на заполненных таблицах
+	if err := migrateOrderToRank("columns", "board_id"); err != nil {
+		return fmt.Errorf("ошибка миграции порядка колонок: %w", err)
+	}
+	if err := migrateOrderToRank("cards", "column_id"); err != nil {
+		return fmt.Errorf("ошибка миграции порядка карточек: %w", err)
+	}
+
+	// Уникальность рангов теперь проверяется только среди неудаленных
+	// (а для карточек — и неархивных) строк
+	if err := dropOutdatedIndex("columns", "idx_columns_board_rank", "deleted_at"); err != nil {
+		return fmt.Errorf("ошибка обновления индекса рангов колонок: %w", err)
+	}
+	if err := dropOutdatedIndex("cards", "idx_cards_column_rank", "archived_at"); err != nil {
+		return fmt.Errorf("ошибка обновления индекса рангов карточек: %w", err)
+	}
+
+	// Таблица связи карточек и меток описана отдельной моделью с каскадным удалением
+	if err := DB.SetupJoinTable(&models.Card{}, "Labels", &models.CardLabel{}); err != nil {
+		return fmt.Errorf("ошибка настройки связи карточек и меток: %w", err)
+	}
+
+	err := DB.AutoMigrate(
+		&models.Board{},
+		&models.Column{},
+		&models.Swimlane{},
+		&models.Card{},
+		&models.Label{},
+		&models.CustomField{},
+		&models.SavedView{},
+		&models.CardLabel{},
+		&models.User{},
+		&models.BoardMember{},
+		&models.Activity{},
+		&models.Checklist{},
+		&models.ChecklistItem{},
+		&models.Attachment{},
+		&models.Comment{},
+		&models.CommentRevision{},
+	)
 	if err != nil {
 		return fmt.Errorf("ошибка миграции: %w", err)
 	}
 
+	if err := migrateCardSearch(); err != nil {
+		return fmt.Errorf("ошибка миграции полнотекстового поиска: %w", err)
+	}
+
+	// Доскам без владельца назначаем владельцем самого раннего участника.
+	// Доски совсем без участников (созданные гостем или до появления ролей)
+	// остаются без владельца, пока их не займет пользователь, знающий пароль
+	// доски (POST /api/boards/:id/claim)
+	err = DB.Exec(`
+		UPDATE board_members SET role = 'owner'
+		WHERE (board_id, user_id) IN (
+			SELECT DISTINCT ON (board_id) board_id, user_id
+			FROM board_members
+			WHERE board_id NOT IN (SELECT board_id FROM board_members WHERE role = 'owner')
+			ORDER BY board_id, created_at ASC
+		)`).Error
+	if err != nil {
+		return fmt.Errorf("ошибка назначения владельцев досок: %w", err)
+	}
+
 	log.Println("Миграция моделей выполнена успешно")
 	return nil
 }
 
+// migrateOrderToRank заменяет столбец order_num таблицы на rank, сохраняя порядок строк внутри группы
+func migrateOrderToRank(table, groupColumn string) error {
+	migrator := DB.Migrator()
+	if !migrator.HasTable(table) || !migrator.HasColumn(table, "order_num") {
+		return nil
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if !tx.Migrator().HasColumn(table, "rank") {
+			if err := tx.Exec(fmt.Sprintf(`ALTER TABLE %q ADD COLUMN "rank" varchar(255) COLLATE "C"`, table)).Error; err != nil {
+				return err
+			}
+		}
+
+		type row struct {
+			ID    string
+			Group string
+		}
+		var rows []row
+		if err := tx.Raw(fmt.Sprintf(`SELECT id, %q AS "group" FROM %q ORDER BY %q, order_num, created_at`,
+			groupColumn, table, groupColumn)).Scan(&rows).Error; err != nil {
+			return err
+		}
+
+		// Раздаем ранги равномерно внутри каждой группы
+		for start := 0; start < len(rows); {
+			end := start
+			for end < len(rows) && rows[end].Group == rows[start].Group {
+				end++
+			}
+
+			ranks := lexorank.Spread(end - start)
+			for i, r := range rows[start:end] {
+				if err := tx.Exec(fmt.Sprintf(`UPDATE %q SET "rank" = ? WHERE id = ?`, table), ranks[i], r.ID).Error; err != nil {
+					return err
+				}
+			}
+			start = end
+		}
+
+		if err := tx.Exec(fmt.Sprintf(`ALTER TABLE %q DROP COLUMN order_num`, table)).Error; err != nil {
+			return err
+		}
+
+		log.Printf("Порядок в таблице %s переведен на ранги (%d строк)", table, len(rows))
+		return nil
+	})
+}
+
+// migrateCardSearch добавляет карточкам генерируемый столбец search_vector с GIN-индексом.
+// Конфигурация russian стеммит кириллицу русским стеммером, а латиницу — английским.
+// Веса: заголовок — A, описание — B, комментарии — C.
+func migrateCardSearch() error {
+	if !DB.Migrator().HasColumn("cards", "search_vector") {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			// Заполняем тексты комментариев у существующих карточек
+			if err := tx.Exec(`
+				UPDATE cards SET comment_text = c.text
+				FROM (
+					SELECT card_id, string_agg(body, E'\n' ORDER BY created_at) AS text
+					FROM comments WHERE deleted_at IS NULL GROUP BY card_id
+				) c
+				WHERE cards.id = c.card_id`).Error; err != nil {
+				return err
+			}
+
+			return tx.Exec(`
+				ALTER TABLE cards ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+					setweight(to_tsvector('russian', coalesce(title, '')), 'A') ||
+					setweight(to_tsvector('russian', coalesce(description, '')), 'B') ||
+					setweight(to_tsvector('russian', coalesce(comment_text, '')), 'C')
+				) STORED`).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return DB.Exec(`CREATE INDEX IF NOT EXISTS idx_cards_search_vector ON cards USING GIN (search_vector)`).Error
+}
+
+// dropOutdatedIndex удаляет индекс, условие WHERE которого не упоминает столбец column,
+// чтобы автомиграция пересоздала его с актуальным условием из модели
+func dropOutdatedIndex(table, index, column string) error {
+	var definitions []string
+	if err := DB.Raw(`SELECT indexdef FROM pg_indexes WHERE tablename = ? AND indexname = ?`, table, index).
+		Scan(&definitions).Error; err != nil {
+		return err
+	}
+
+	if len(definitions) == 0 {
+		return nil
+	}
+	if where := strings.Index(definitions[0], " WHERE "); where >= 0 && strings.Contains(definitions[0][where:], column) {
+		return nil
+	}
+
+	log.Printf("Пересоздаем индекс %s с новым условием", index)
+	return DB.Exec(fmt.Sprintf(`DROP INDEX %q`, index)).Error
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,72 @@
+// Package events доставляет изменения досок подписанным клиентам в реальном времени.
+package events
+
+import (
+	"time"
+)
+
+// Type определяет вид события на доске
+type Type string
+
+const (
+	BoardCreated         Type = "board.created"
+	BoardAccessChanged   Type = "board.access_changed"
+	BoardUpdated         Type = "board.updated"
+	BoardDeleted         Type = "board.deleted"
+	CardCreated          Type = "card.created"
+	CardUpdated          Type = "card.updated"
+	CardMoved            Type = "card.moved"
+	CardDeleted          Type = "card.deleted"
+	CardRestored         Type = "card.restored"
+	CardArchived         Type = "card.archived"
+	CardUnarchived       Type = "card.unarchived"
+	ColumnCreated        Type = "column.created"
+	ColumnUpdated        Type = "column.updated"
+	ColumnMoved          Type = "column.moved"
+	ColumnDeleted        Type = "column.deleted"
+	ColumnRestored       Type = "column.restored"
+	SwimlaneCreated      Type = "swimlane.created"
+	SwimlaneUpdated      Type = "swimlane.updated"
+	SwimlaneMoved        Type = "swimlane.moved"
+	SwimlaneDeleted      Type = "swimlane.deleted"
+	LabelCreated         Type = "label.created"
+	LabelUpdated         Type = "label.updated"
+	LabelDeleted         Type = "label.deleted"
+	CustomFieldCreated   Type = "custom_field.created"
+	CustomFieldUpdated   Type = "custom_field.updated"
+	CustomFieldDeleted   Type = "custom_field.deleted"
+	SavedViewCreated     Type = "view.created"
+	SavedViewUpdated     Type = "view.updated"
+	SavedViewDeleted     Type = "view.deleted"
+	ChecklistCreated     Type = "checklist.created"
+	ChecklistUpdated     Type = "checklist.updated"
+	ChecklistDeleted     Type = "checklist.deleted"
+	ChecklistItemCreated Type = "checklist_item.created"
+	ChecklistItemUpdated Type = "checklist_item.updated"
+	ChecklistItemMoved   Type = "checklist_item.moved"
+	ChecklistItemDeleted Type = "checklist_item.deleted"
+	AttachmentCreated    Type = "attachment.created"
+	AttachmentDeleted    Type = "attachment.deleted"
+	CommentCreated       Type = "comment.created"
+	CommentUpdated       Type = "comment.updated"
+	CommentDeleted       Type = "comment.deleted"
+)
+
+// Event описывает изменение на доске
+type Event struct {
+	Type    Type        `json:"type"`
+	BoardID string      `json:"board_id"`
+	ActorID string      `json:"actor_id,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+	At      time.Time   `json:"at"`
+}
+
+// Broker рассылает события подписчикам доски.
+// Реализации должны быть безопасны для одновременного использования.
+type Broker interface {
+	// Publish отправляет событие всем подписчикам доски события
+	Publish(event Event) error
+	// Subscribe подписывает на события доски. Возвращаемая функция отменяет подписку
+	// и закрывает канал.
+	Subscribe(boardID string) (<-chan Event, func())
+}
@@ -0,0 +1,62 @@
+package events
+
+import (
+	"sync"
+)
+
+// subscriberBuffer — сколько событий может ждать отправки медленному подписчику
+const subscriberBuffer = 64
+
+// MemoryBroker рассылает события внутри одного процесса
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan Event]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: make(map[string]map[chan Event]struct{}),
+	}
+}
+
+// Publish отправляет событие подписчикам доски. Если буфер подписчика заполнен,
+// событие для него пропускается, чтобы медленный клиент не задерживал остальных.
+func (b *MemoryBroker) Publish(event Event) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for ch := range b.subscribers[event.BoardID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe подписывает на события доски
+func (b *MemoryBroker) Subscribe(boardID string) (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.subscribers[boardID] == nil {
+		b.subscribers[boardID] = make(map[chan Event]struct{})
+	}
+	b.subscribers[boardID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers[boardID], ch)
+			if len(b.subscribers[boardID]) == 0 {
+				delete(b.subscribers, boardID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
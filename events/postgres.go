@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// notifyChannel — канал PostgreSQL, через который экземпляры сервера обмениваются событиями
+const notifyChannel = "board_events"
+
+// maxNotifyPayload — предел размера NOTIFY в PostgreSQL (8000 байт) с запасом
+const maxNotifyPayload = 7900
+
+// PostgresBroker рассылает события между несколькими экземплярами сервера через
+// LISTEN/NOTIFY. Каждый экземпляр слушает канал и раздает события своим подписчикам.
+type PostgresBroker struct {
+	db    *gorm.DB
+	dsn   string
+	local *MemoryBroker
+}
+
+// NewPostgresBroker создает брокер и запускает прослушивание канала.
+// Через db отправляются уведомления, dsn используется для отдельного соединения LISTEN.
+func NewPostgresBroker(db *gorm.DB, dsn string) *PostgresBroker {
+	b := &PostgresBroker{
+		db:    db,
+		dsn:   dsn,
+		local: NewMemoryBroker(),
+	}
+	go b.listen()
+	return b
+}
+
+// Publish отправляет событие через NOTIFY. Подписчики этого экземпляра получат его
+// вместе с остальными, когда уведомление вернется от PostgreSQL.
+func (b *PostgresBroker) Publish(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// Слишком большие события отправляем без данных: клиент перезагрузит доску
+	if len(payload) > maxNotifyPayload {
+		event.Data = nil
+		if payload, err = json.Marshal(event); err != nil {
+			return err
+		}
+	}
+
+	return b.db.Exec("SELECT pg_notify(?, ?)", notifyChannel, string(payload)).Error
+}
+
+// Subscribe подписывает на события доски
+func (b *PostgresBroker) Subscribe(boardID string) (<-chan Event, func()) {
+	return b.local.Subscribe(boardID)
+}
+
+// listen держит соединение LISTEN и переподключается при обрыве
+func (b *PostgresBroker) listen() {
+	for {
+		if err := b.listenOnce(context.Background()); err != nil {
+			log.Println("Ошибка прослушивания событий PostgreSQL:", err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (b *PostgresBroker) listenOnce(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			log.Println("Некорректное событие PostgreSQL:", err)
+			continue
+		}
+
+		b.local.Publish(event)
+	}
+}
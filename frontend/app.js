@@ -2,11 +2,23 @@
 let currentBoardId = null;
 let currentColumnId = null;
 let editingCardId = null;
+let editingCardVersion = null;
 let draggedCard = null;
+let boardEvents = null;
+let refreshTimer = null;
+let customFields = [];
 
 // API базовый URL
 const API_BASE = '/api';
 
+// Названия приоритетов карточек
+const priorityNames = {
+    low: 'Низкий',
+    medium: 'Средний',
+    high: 'Высокий',
+    urgent: 'Срочно'
+};
+
 // Инициализация при загрузке страницы
 document.addEventListener('DOMContentLoaded', function() {
     setupEventListeners();
@@ -18,6 +30,9 @@ function setupEventListeners() {
     // Форма входа
     document.getElementById('login-form-element').addEventListener('submit', loginToBoard);
 
+    // Форма создания доски
+    document.getElementById('create-form-element').addEventListener('submit', createBoard);
+
     // Форма карт��чки
     document.getElementById('card-form').addEventListener('submit', saveCard);
 
@@ -29,10 +44,20 @@ function setupEventListeners() {
     });
 }
 
+// Адрес доски с фильтром из ссылки страницы: ?q=<фильтр>, ?view=<id представления>
+// и ?cf=<id поля>:<значение> передаются в API как есть
+function boardURL() {
+    const page = new URLSearchParams(window.location.search);
+    const params = new URLSearchParams();
+    ['q', 'view', 'cf'].forEach(name => page.getAll(name).forEach(value => params.append(name, value)));
+    const query = params.toString();
+    return query ? `${API_BASE}/board?${query}` : `${API_BASE}/board`;
+}
+
 // Проверка статуса авторизации
 async function checkAuthStatus() {
     try {
-        const response = await fetch(`${API_BASE}/board`, {
+        const response = await fetch(boardURL(), {
             credentials: 'include'
         });
 
@@ -46,6 +71,45 @@ async function checkAuthStatus() {
 }
 
 
+// Создание новой доски
+async function createBoard(e) {
+    e.preventDefault();
+
+    const name = document.getElementById('board-name').value;
+    const password = document.getElementById('create-password').value;
+    const creationKey = document.getElementById('creation-key').value;
+
+    const errorDiv = document.getElementById('create-error');
+    errorDiv.classList.add('hidden');
+
+    const headers = {
+        'Content-Type': 'application/json',
+    };
+    if (creationKey) {
+        headers['X-Creation-Key'] = creationKey;
+    }
+
+    try {
+        const response = await fetch(`${API_BASE}/boards`, {
+            method: 'POST',
+            headers,
+            credentials: 'include',
+            body: JSON.stringify({ name, password }),
+        });
+
+        if (response.ok) {
+            // Создатель сразу авторизован в новой доске
+            const board = await response.json();
+            showBoard(board);
+        } else {
+            const error = await response.json();
+            showError('create-error', error.error);
+        }
+    } catch (error) {
+        showError('create-error', 'Ошибка соединения с сервером');
+    }
+}
+
 // Вход в существующую доску
 async function loginToBoard(e) {
     e.preventDefault();
@@ -68,7 +132,7 @@ async function loginToBoard(e) {
 
         if (response.ok) {
             // Получаем данные доски после успешного входа
-            const boardResponse = await fetch(`${API_BASE}/board`, {
+            const boardResponse = await fetch(boardURL(), {
                 credentials: 'include'
             });
 
@@ -91,6 +155,7 @@ function showBoard(board) {
 
     // Скрываем форму входа и показываем доску
     document.getElementById('login-form').classList.add('hidden');
+    document.getElementById('create-form').classList.add('hidden');
     document.getElementById('board-container').classList.remove('hidden');
 
     // Обновляем заголовок и ID доски
@@ -99,16 +164,44 @@ function showBoard(board) {
 
     // Отображаем колонки
     renderBoard(board);
+
+    // Подписываемся на изменения доски от других участников
+    subscribeToBoardEvents();
+}
+
+// Подписка на события доски через Server-Sent Events
+function subscribeToBoardEvents() {
+    if (boardEvents) {
+        boardEvents.close();
+    }
+
+    boardEvents = new EventSource(`${API_BASE}/board/events`, { withCredentials: true });
+
+    const eventTypes = [
+        'card.created', 'card.updated', 'card.moved', 'card.deleted',
+        'card.restored', 'card.archived', 'card.unarchived',
+        'column.created', 'column.updated', 'column.moved', 'column.deleted', 'column.restored',
+        'swimlane.created', 'swimlane.updated', 'swimlane.moved', 'swimlane.deleted',
+        'custom_field.created', 'custom_field.updated', 'custom_field.deleted',
+    ];
+    eventTypes.forEach(type => boardEvents.addEventListener(type, scheduleRefresh));
+}
+
+// Обновляем доску не чаще одного раза за короткий промежуток
+function scheduleRefresh() {
+    clearTimeout(refreshTimer);
+    refreshTimer = setTimeout(refreshBoard, 200);
 }
 
 // Отрисовка доски с колонками
 function renderBoard(board) {
     const boardElement = document.getElementById('board');
     boardElement.innerHTML = '';
+    customFields = board.custom_fields || [];
 
     // Создаем колонки из данных доски
-    board.columns.forEach(column => {
-        const columnElement = createColumnElement(column);
+    board.columns.forEach((column, index) => {
+        const columnElement = createColumnElement(column, index + 1);
         boardElement.appendChild(columnElement);
     });
 
@@ -123,22 +216,24 @@ function renderBoard(board) {
     boardElement.appendChild(addColumnButton);
 }
 
-// Создание элемента колонки
-function createColumnElement(column) {
+// Создание элемента колонки (position — позиция колонки на доске, начиная с 1)
+function createColumnElement(column, position) {
     const columnDiv = document.createElement('div');
     columnDiv.className = 'column';
     columnDiv.dataset.columnId = column.id;
 
     columnDiv.innerHTML = `
         <div class="column-header">
-            <h3 class="column-title">${column.name}</h3>
+            <h3 class="column-title">${column.name}${column.wip_limit ? ` <span class="column-wip${column.over_wip_limit ? ' over' : ''}" title="WIP-лимит${column.wip_limit_hard ? ' (жесткий)' : ''}">${column.card_count}/${column.wip_limit}</span>` : ''}</h3>
             <div class="column-actions">
                 <button class="btn add-card-btn" onclick="openCardModal('${column.id}')">+ Карточка</button>
+                <button class="btn-icon" onclick="moveColumn('${column.id}', ${position - 1})" title="Сдвинуть влево">←</button>
+                <button class="btn-icon" onclick="moveColumn('${column.id}', ${position + 1})" title="Сдвинуть вправо">→</button>
                 <button class="btn-icon" onclick="editColumn('${column.id}', '${column.name}')" title="Редактировать колонку">✎</button>
                 <button class="btn-icon btn-danger-icon" onclick="deleteColumn('${column.id}')" title="Удалить колонку">×</button>
             </div>
         </div>
-        <div class="cards" id="cards-${column.id}">
+        <div class="cards" id="cards-${column.id}" ondrop="dropCard(event, '${column.id}')" ondragover="allowDrop(event)">
             ${column.cards.map(card => createCardHTML(card)).join('')}
         </div>
         <div class="drop-zone" ondrop="dropCard(event, '${column.id}')" ondragover="allowDrop(event)">
@@ -149,6 +244,19 @@ function createColumnElement(column) {
     return columnDiv;
 }
 
+// Значения пользовательских полей карточки в порядке полей доски
+function createCustomFieldsHTML(card) {
+    const values = card.custom_fields || {};
+    const items = customFields.filter(field => values[field.id] !== undefined).map(field => {
+        let value = values[field.id];
+        if (field.type === 'checkbox') value = value ? '✓' : '✗';
+        if (field.type === 'multi_select') value = value.join(', ');
+        if (field.type === 'url') value = `<a href="${value}" target="_blank" rel="noopener">${value}</a>`;
+        return `<div class="card-field"><span class="card-field-name">${field.name}:</span> ${value}</div>`;
+    });
+    return items.length ? `<div class="card-fields">${items.join('')}</div>` : '';
+}
+
 // Создание HTML карточки
 function createCardHTML(card) {
     const deadlineHTML = card.deadline ? getDeadlineHTML(card.deadline) : '';
@@ -160,11 +268,18 @@ function createCardHTML(card) {
                 <button onclick="editCard('${card.id}')" title="Редактировать">✎</button>
                 <button onclick="deleteCard('${card.id}')" title="Удалить">×</button>
             </div>
+            ${(card.labels || []).length ? `<div class="card-labels">${card.labels.map(label =>
+                `<span class="card-label" style="background:${label.color}">${label.name}</span>`).join('')}</div>` : ''}
             <div class="card-title">${card.title}</div>
             ${card.description ? `<div class="card-description">${card.description}</div>` : ''}
+            ${createCustomFieldsHTML(card)}
             <div class="card-footer">
                 ${card.assignee ? `<div class="card-assignee">${card.assignee}</div>` : ''}
                 ${deadlineHTML}
+                ${card.priority ? `<div class="card-priority ${card.priority}" title="Приоритет">${priorityNames[card.priority]}</div>` : ''}
+                ${card.estimate != null ? `<div class="card-estimate" title="Оценка">⏱ ${card.estimate}</div>` : ''}
+                ${card.checklist_progress ? `<div class="card-checklist" title="Чек-листы">☑ ${card.checklist_progress.done}/${card.checklist_progress.total}</div>` : ''}
+                ${card.comment_count ? `<div class="card-comments" title="Комментарии">💬 ${card.comment_count}</div>` : ''}
             </div>
         </div>
     `;
@@ -217,11 +332,25 @@ function dropCard(e, columnId) {
     e.currentTarget.classList.remove('drag-over');
 
     const cardId = e.dataTransfer.getData('text/plain');
-    moveCardToColumn(cardId, columnId);
+    const order = getDropPosition(columnId, cardId, e.clientY);
+    moveCardToColumn(cardId, columnId, order);
 }
 
-// Перемещение карточки между колонками
-async function moveCardToColumn(cardId, columnId) {
+// Позиция (с 1) для вставки карточки по координате курсора; 0 — в конец колонки
+function getDropPosition(columnId, cardId, clientY) {
+    const cards = Array.from(document.querySelectorAll(`#cards-${columnId} .card`))
+        .filter(card => card.dataset.cardId !== cardId);
+
+    const index = cards.findIndex(card => {
+        const rect = card.getBoundingClientRect();
+        return clientY < rect.top + rect.height / 2;
+    });
+
+    return index === -1 ? 0 : index + 1;
+}
+
+// Перемещение карточки на позицию в колонке
+async function moveCardToColumn(cardId, columnId, order = 0) {
     try {
         const response = await fetch(`${API_BASE}/cards/${cardId}/move`, {
             method: 'PUT',
@@ -229,7 +358,7 @@ async function moveCardToColumn(cardId, columnId) {
                 'Content-Type': 'application/json',
             },
             credentials: 'include',
-            body: JSON.stringify({ column_id: columnId }),
+            body: JSON.stringify({ column_id: columnId, order }),
         });
 
         if (response.ok) {
@@ -286,17 +415,7 @@ async function loadCardData(cardId) {
             }
 
             if (foundCard) {
-                document.getElementById('card-title').value = foundCard.title;
-                document.getElementById('card-description').value = foundCard.description || '';
-                document.getElementById('card-assignee').value = foundCard.assignee || '';
-                
-                // Обрабатываем дедлайн для поля datetime-local
-                if (foundCard.deadline) {
-                    const date = new Date(foundCard.deadline);
-                    const offset = date.getTimezoneOffset() * 60000;
-                    const localDate = new Date(date.getTime() - offset);
-                    document.getElementById('card-deadline').value = localDate.toISOString().slice(0, 16);
-                }
+                fillCardForm(foundCard);
             }
         }
     } catch (error) {
@@ -304,11 +423,30 @@ async function loadCardData(cardId) {
     }
 }
 
+// Заполнение формы карточки данными с сервера
+function fillCardForm(card) {
+    editingCardVersion = card.version;
+    document.getElementById('card-title').value = card.title;
+    document.getElementById('card-description').value = card.description || '';
+    document.getElementById('card-assignee').value = card.assignee || '';
+
+    // Обрабатываем дедлайн для поля datetime-local
+    if (card.deadline) {
+        const date = new Date(card.deadline);
+        const offset = date.getTimezoneOffset() * 60000;
+        const localDate = new Date(date.getTime() - offset);
+        document.getElementById('card-deadline').value = localDate.toISOString().slice(0, 16);
+    } else {
+        document.getElementById('card-deadline').value = '';
+    }
+}
+
 // Закрытие модального окна
 function closeCardModal() {
     document.getElementById('card-modal').style.display = 'none';
     currentColumnId = null;
     editingCardId = null;
+    editingCardVersion = null;
 }
 
 // Сохранение карточки
@@ -335,7 +473,7 @@ async function saveCard(e) {
                     'Content-Type': 'application/json',
                 },
                 credentials: 'include',
-                body: JSON.stringify({ title, description, assignee, deadline }),
+                body: JSON.stringify({ title, description, assignee, deadline, version: editingCardVersion }),
             });
         } else {
             // Создание новой карточки
@@ -358,6 +496,16 @@ async function saveCard(e) {
         if (response.ok) {
             closeCardModal();
             await refreshBoard();
+        } else if (response.status === 409) {
+            // Карточку успели изменить: предлагаем перезаписать или взять чужие изменения
+            const conflict = await response.json();
+            if (confirm('Карточку изменил другой участник. Сохранить ваши изменения поверх его?')) {
+                editingCardVersion = conflict.current.version;
+                await saveCard(e);
+            } else {
+                fillCardForm(conflict.current);
+                showError('modal-error', 'Загружена актуальная версия карточки');
+            }
         } else {
             const error = await response.json();
             showError('modal-error', error.error);
@@ -482,12 +630,12 @@ function editColumn(columnId, currentName) {
 
 // Удаление колонки
 async function deleteColumn(columnId) {
-    if (!confirm('Удалить колонку? Все карточки в ней будут также удалены.')) {
+    if (!confirm('Удалить колонку? Все карточки в ней будут также перемещены в корзину.')) {
         return;
     }
 
     try {
-        const response = await fetch(`${API_BASE}/columns/${columnId}`, {
+        const response = await fetch(`${API_BASE}/columns/${columnId}?mode=delete`, {
             method: 'DELETE',
             credentials: 'include',
         });
@@ -503,10 +651,37 @@ async function deleteColumn(columnId) {
     }
 }
 
+// Перемещение колонки на новую позицию
+async function moveColumn(columnId, order) {
+    if (order < 1) {
+        return;
+    }
+
+    try {
+        const response = await fetch(`${API_BASE}/columns/${columnId}/move`, {
+            method: 'PUT',
+            headers: {
+                'Content-Type': 'application/json',
+            },
+            credentials: 'include',
+            body: JSON.stringify({ order }),
+        });
+
+        if (response.ok) {
+            await refreshBoard();
+        } else {
+            const error = await response.json();
+            alert(`Ошибка перемещения колонки: ${error.error}`);
+        }
+    } catch (error) {
+        alert('Ошибка соединения с сервером');
+    }
+}
+
 // Обновление доски
 async function refreshBoard() {
     try {
-        const response = await fetch(`${API_BASE}/board`, {
+        const response = await fetch(boardURL(), {
             credentials: 'include'
         });
 
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ActivityHandler struct {
+	activityService *services.ActivityService
+}
+
+func NewActivityHandler(activityService *services.ActivityService) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: activityService,
+	}
+}
+
+// List возвращает журнал изменений текущей доски с фильтрами и курсорной пагинацией
+func (h *ActivityHandler) List(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var query models.ActivityQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверные параметры запроса",
+		})
+	}
+
+	page, err := h.activityService.List(boardID, query)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(page)
+}
+
+// CardHistory возвращает историю изменений одной карточки
+func (h *ActivityHandler) CardHistory(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var query models.ActivityQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверные параметры запроса",
+		})
+	}
+	query.CardID = c.Params("cardId")
+
+	page, err := h.activityService.List(boardID, query)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(page)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService *services.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+	}
+}
+
+// List возвращает вложения карточки
+func (h *AttachmentHandler) List(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	attachments, err := h.attachmentService.List(boardID, c.Params("cardId"))
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(attachments)
+}
+
+// Upload прикрепляет к карточке файл из поля формы "file" (multipart/form-data)
+func (h *AttachmentHandler) Upload(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Файл не передан",
+		})
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Ошибка чтения файла",
+		})
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.Upload(actorFromCtx(c), boardID, c.Params("cardId"),
+		header.Filename, file, header.Size)
+	if err != nil {
+		status := 400
+		if errors.Is(err, services.ErrAttachmentTooLarge) || errors.Is(err, services.ErrQuotaExceeded) {
+			status = 413
+		}
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(attachment)
+}
+
+// Download отдает содержимое вложения для скачивания
+func (h *AttachmentHandler) Download(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	attachment, content, err := h.attachmentService.Open(boardID, c.Params("cardId"), c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Файл всегда скачивается, а не открывается в браузере, чтобы загруженный
+	// HTML не выполнялся в контексте приложения
+	c.Set("Content-Type", attachment.ContentType)
+	c.Set("X-Content-Type-Options", "nosniff")
+	c.Set("Content-Disposition", contentDisposition(attachment.FileName))
+
+	// Fiber закроет content после отправки
+	return c.SendStream(content, int(attachment.Size))
+}
+
+// Delete удаляет вложение
+func (h *AttachmentHandler) Delete(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	err := h.attachmentService.Delete(actorFromCtx(c), boardID, c.Params("cardId"), c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Вложение удалено",
+	})
+}
+
+// contentDisposition формирует заголовок скачивания с именем файла в ASCII и UTF-8 (RFC 6266)
+func contentDisposition(fileName string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, fileName)
+
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`,
+		fallback, url.PathEscape(fileName))
+}
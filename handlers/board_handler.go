@@ -1,6 +1,13 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"task-board/middleware"
@@ -12,16 +19,70 @@ import (
 
 type BoardHandler struct {
 	boardService *services.BoardService
+	userService  *services.UserService
 }
 
-func NewBoardHandler(boardService *services.BoardService) *BoardHandler {
+func NewBoardHandler(boardService *services.BoardService, userService *services.UserService) *BoardHandler {
 	return &BoardHandler{
 		boardService: boardService,
+		userService:  userService,
 	}
 }
 
-// CreateBoard создает новую доску
+// actorFromCtx возвращает автора изменения из данных токена
+func actorFromCtx(c *fiber.Ctx) services.Actor {
+	userID, _ := c.Locals("user_id").(string)
+	role, _ := c.Locals("role").(models.Role)
+	return services.Actor{UserID: userID, Role: role}
+}
+
+// versionFromIfMatch берет ожидаемую версию из заголовка If-Match, если она не передана в теле
+func versionFromIfMatch(c *fiber.Ctx, version **int) error {
+	header := strings.TrimSpace(c.Get("If-Match"))
+	if *version != nil || header == "" || header == "*" {
+		return nil
+	}
+
+	value, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil {
+		return errors.New("Неверный заголовок If-Match")
+	}
+
+	*version = &value
+	return nil
+}
+
+// setVersionETag передает версию объекта в заголовке ETag
+func setVersionETag(c *fiber.Ctx, version int) {
+	c.Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// asConflict возвращает ошибку конфликта версий, если err ею является
+func asConflict(err error) *services.ConflictError {
+	var conflict *services.ConflictError
+	if errors.As(err, &conflict) {
+		return conflict
+	}
+	return nil
+}
+
+// setAuthCookie сохраняет JWT токен в HTTP-only cookie
+func setAuthCookie(c *fiber.Ctx, token string) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "auth_token",
+		Value:    token,
+		Expires:  time.Now().Add(24 * time.Hour),
+		HTTPOnly: true,
+		Secure:   false, // Установите true в production с HTTPS
+		SameSite: "Lax",
+	})
+}
+
+// CreateBoard создает новую доску. Пользователь становится её участником,
+// а без учетной записи обязателен пароль для гостевого доступа.
 func (h *BoardHandler) CreateBoard(c *fiber.Ctx) error {
+	actor := actorFromCtx(c)
+
 	var req models.CreateBoardRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(models.ErrorResponse{
@@ -29,47 +90,203 @@ func (h *BoardHandler) CreateBoard(c *fiber.Ctx) error {
 		})
 	}
 
-	if req.Name == "" || req.Password == "" {
+	if req.Name == "" || (actor.IsGuest() && req.Password == "") {
 		return c.Status(400).JSON(models.ErrorResponse{
 			Error: "Имя доски и пароль обязательны",
 		})
 	}
 
-	if len(req.Password) < 6 {
+	if req.Password != "" && len(req.Password) < 6 {
 		return c.Status(400).JSON(models.ErrorResponse{
 			Error: "Пароль должен содержать минимум 6 символ����в",
 		})
 	}
 
-	board, err := h.boardService.CreateBoard(req.Name, req.Password)
+	board, err := h.boardService.CreateBoard(actor, req.Name, req.Password)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
 			Error: "Ошибка создания доски",
 		})
 	}
 
-	// Генерируем JWT токен
-	token, err := middleware.GenerateToken(board.ID)
+	token, err := h.issueBoardToken(actor, board.ID)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
 			Error: "Ошибка генерации токена",
 		})
 	}
 
-	// Устанавливаем HTTP-only cookie
-	c.Cookie(&fiber.Cookie{
-		Name:     "auth_token",
-		Value:    token,
-		Expires:  time.Now().Add(24 * time.Hour),
-		HTTPOnly: true,
-		Secure:   false, // Установите true в production с HTTPS
-		SameSite: "Lax",
+	setAuthCookie(c, token)
+
+	return c.Status(201).JSON(board)
+}
+
+// issueBoardToken генерирует JWT токен для созданной доски: гостю — гостевой,
+// пользователю — токен учетной записи с этой доской
+func (h *BoardHandler) issueBoardToken(actor services.Actor, boardID string) (string, error) {
+	if actor.IsGuest() {
+		return middleware.GenerateToken(boardID)
+	}
+	return issueUserToken(h.userService, actor.UserID, boardID)
+}
+
+// ExportBoard выгружает доску документом JSON. Документ пишется в ответ потоком,
+// поэтому ошибка посреди выгрузки обрывает ответ и клиент получает неполный JSON.
+func (h *BoardHandler) ExportBoard(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	write, err := h.boardService.ExportBoard(boardID)
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: "Доска не найдена",
+		})
+	}
+
+	c.Set("Content-Type", "application/json; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="board-%s.json"`, boardID))
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := write(w); err != nil {
+			log.Println("Ошибка экспорта доски:", err)
+			return
+		}
+		w.Flush()
 	})
 
-	return c.JSON(board)
+	return nil
+}
+
+// ImportBoard создает новую доску из документа экспорта. Как и при создании доски,
+// пользователь становится её владельцем, а без учетной записи обязателен пароль
+// для гостевого доступа (заголовок X-Board-Password).
+func (h *BoardHandler) ImportBoard(c *fiber.Ctx) error {
+	actor := actorFromCtx(c)
+	password := c.Get("X-Board-Password")
+
+	var doc models.BoardExport
+	if err := c.BodyParser(&doc); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат документа",
+		})
+	}
+
+	if actor.IsGuest() && password == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Для импорта без учетной записи нужен пароль доски",
+		})
+	}
+	if password != "" && len(password) < 6 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Пароль должен содержать минимум 6 символов",
+		})
+	}
+
+	board, err := h.boardService.ImportBoard(actor, &doc, password)
+	if err != nil {
+		return c.Status(validationStatus(err, 500)).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	token, err := h.issueBoardToken(actor, board.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: "Ошибка генерации токена",
+		})
+	}
+
+	setAuthCookie(c, token)
+
+	return c.Status(201).JSON(board)
+}
+
+// ExportCardsCSV выгружает карточки доски в CSV
+func (h *BoardHandler) ExportCardsCSV(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var buf bytes.Buffer
+	if err := h.boardService.ExportCardsCSV(boardID, &buf); err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="board-%s-cards.csv"`, boardID))
+	return c.Send(buf.Bytes())
 }
 
-// Login вход в доску по паролю
+// ImportCardsCSV создает и обновляет карточки по CSV из тела запроса.
+// Ошибки отдельных строк возвращаются в отчете, а не прерывают импорт.
+func (h *BoardHandler) ImportCardsCSV(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var q models.CSVImportQuery
+	if err := c.QueryParser(&q); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверные параметры запроса",
+		})
+	}
+
+	result, err := h.boardService.ImportCardsCSV(actorFromCtx(c), boardID, c.Body(), q.Mapping)
+	if err != nil {
+		return c.Status(validationStatus(err, 500)).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(result)
+}
+
+// ImportTrello создает доску из JSON-экспорта Trello. С dry_run=true доска не создается,
+// а возвращается отчет о том, что было бы создано.
+func (h *BoardHandler) ImportTrello(c *fiber.Ctx) error {
+	actor := actorFromCtx(c)
+	password := c.Get("X-Board-Password")
+
+	var q models.TrelloImportQuery
+	if err := c.QueryParser(&q); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверные параметры запроса",
+		})
+	}
+
+	if !q.DryRun {
+		if actor.IsGuest() && password == "" {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error: "Для импорта без учетной записи нужен пароль доски",
+			})
+		}
+		if password != "" && len(password) < 6 {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error: "Пароль должен содержать минимум 6 символов",
+			})
+		}
+	}
+
+	result, err := h.boardService.ImportTrello(actor, c.Body(), password, q.DryRun)
+	if err != nil {
+		return c.Status(validationStatus(err, 500)).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if q.DryRun {
+		return c.JSON(result)
+	}
+
+	token, err := h.issueBoardToken(actor, result.Board.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: "Ошибка генерации токена",
+		})
+	}
+
+	setAuthCookie(c, token)
+
+	return c.Status(201).JSON(result)
+}
+
+// Login гостевой вход в доску по паролю
 func (h *BoardHandler) Login(c *fiber.Ctx) error {
 	boardID := c.Params("id")
 
@@ -82,7 +299,7 @@ func (h *BoardHandler) Login(c *fiber.Ctx) error {
 
 	if err := h.boardService.ValidatePassword(boardID, req.Password); err != nil {
 		return c.Status(401).JSON(models.ErrorResponse{
-			Error: "Неверный пароль",
+			Error: err.Error(),
 		})
 	}
 
@@ -94,15 +311,7 @@ func (h *BoardHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	// Устанавливаем HTTP-only cookie
-	c.Cookie(&fiber.Cookie{
-		Name:     "auth_token",
-		Value:    token,
-		Expires:  time.Now().Add(24 * time.Hour),
-		HTTPOnly: true,
-		Secure:   false,
-		SameSite: "Lax",
-	})
+	setAuthCookie(c, token)
 
 	return c.JSON(models.LoginResponse{
 		Message: "Успешный вход",
@@ -114,7 +323,20 @@ func (h *BoardHandler) Login(c *fiber.Ctx) error {
 func (h *BoardHandler) GetBoard(c *fiber.Ctx) error {
 	boardID := c.Locals("board_id").(string)
 
-	board, err := h.boardService.GetBoard(boardID)
+	var q models.BoardQuery
+	if err := c.QueryParser(&q); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверные параметры запроса",
+		})
+	}
+
+	board, err := h.boardService.GetBoard(boardID, q)
+	var invalid *services.ValidationError
+	if errors.As(err, &invalid) {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
 	if err != nil {
 		return c.Status(404).JSON(models.ErrorResponse{
 			Error: "Доска не найдена",
@@ -141,13 +363,25 @@ func (h *BoardHandler) CreateCard(c *fiber.Ctx) error {
 		})
 	}
 
-	card, err := h.boardService.CreateCard(boardID, req)
+	if !req.Priority.Valid() {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неизвестный приоритет",
+		})
+	}
+	if req.Estimate != nil && *req.Estimate < 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Оценка карточки не может быть отрицательной",
+		})
+	}
+
+	card, err := h.boardService.CreateCard(actorFromCtx(c), boardID, req)
 	if err != nil {
-		return c.Status(500).JSON(models.ErrorResponse{
+		return c.Status(wipLimitStatus(err, validationStatus(err, 500))).JSON(models.ErrorResponse{
 			Error: err.Error(),
 		})
 	}
 
+	setVersionETag(c, card.Version)
 	return c.Status(201).JSON(card)
 }
 
@@ -163,13 +397,37 @@ func (h *BoardHandler) UpdateCard(c *fiber.Ctx) error {
 		})
 	}
 
-	card, err := h.boardService.UpdateCard(boardID, cardID, req)
+	if err := versionFromIfMatch(c, &req.Version); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if req.Priority != nil && !req.Priority.Valid() {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неизвестный приоритет",
+		})
+	}
+	if req.Estimate != nil && *req.Estimate < 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Оценка карточки не может быть отрицательной",
+		})
+	}
+
+	card, err := h.boardService.UpdateCard(actorFromCtx(c), boardID, cardID, req)
 	if err != nil {
-		return c.Status(404).JSON(models.ErrorResponse{
+		if conflict := asConflict(err); conflict != nil {
+			return c.Status(409).JSON(models.ConflictResponse{
+				Error:   conflict.Error(),
+				Current: conflict.Current,
+			})
+		}
+		return c.Status(validationStatus(err, 404)).JSON(models.ErrorResponse{
 			Error: err.Error(),
 		})
 	}
 
+	setVersionETag(c, card.Version)
 	return c.JSON(card)
 }
 
@@ -185,28 +443,41 @@ func (h *BoardHandler) MoveCard(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := versionFromIfMatch(c, &req.Version); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
 	if req.ColumnID == "" {
 		return c.Status(400).JSON(models.ErrorResponse{
 			Error: "ID колонки обязателен",
 		})
 	}
 
-	card, err := h.boardService.MoveCard(boardID, cardID, req)
+	card, err := h.boardService.MoveCard(actorFromCtx(c), boardID, cardID, req)
 	if err != nil {
-		return c.Status(404).JSON(models.ErrorResponse{
+		if conflict := asConflict(err); conflict != nil {
+			return c.Status(409).JSON(models.ConflictResponse{
+				Error:   conflict.Error(),
+				Current: conflict.Current,
+			})
+		}
+		return c.Status(wipLimitStatus(err, 404)).JSON(models.ErrorResponse{
 			Error: err.Error(),
 		})
 	}
 
+	setVersionETag(c, card.Version)
 	return c.JSON(card)
 }
 
-// DeleteCard удаляет карточку
+// DeleteCard перемещает карточку в корзину
 func (h *BoardHandler) DeleteCard(c *fiber.Ctx) error {
 	boardID := c.Locals("board_id").(string)
 	cardID := c.Params("cardId")
 
-	err := h.boardService.DeleteCard(boardID, cardID)
+	err := h.boardService.DeleteCard(actorFromCtx(c), boardID, cardID)
 	if err != nil {
 		return c.Status(404).JSON(models.ErrorResponse{
 			Error: err.Error(),
@@ -214,7 +485,7 @@ func (h *BoardHandler) DeleteCard(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Карточка удалена",
+		"message": "Карточка перемещена в корзину",
 	})
 }
 
@@ -250,7 +521,7 @@ func (h *BoardHandler) CreateColumn(c *fiber.Ctx) error {
 		})
 	}
 
-	column, err := h.boardService.CreateColumn(boardID, req)
+	column, err := h.boardService.CreateColumn(actorFromCtx(c), boardID, req)
 	if err != nil {
 		return c.Status(500).JSON(models.ErrorResponse{
 			Error: err.Error(),
@@ -272,28 +543,285 @@ func (h *BoardHandler) UpdateColumn(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := versionFromIfMatch(c, &req.Version); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
 	if req.Name == "" {
 		return c.Status(400).JSON(models.ErrorResponse{
 			Error: "Название колонки обязательно",
 		})
 	}
 
-	column, err := h.boardService.UpdateColumn(boardID, columnID, req)
+	column, err := h.boardService.UpdateColumn(actorFromCtx(c), boardID, columnID, req)
 	if err != nil {
+		if conflict := asConflict(err); conflict != nil {
+			return c.Status(409).JSON(models.ConflictResponse{
+				Error:   conflict.Error(),
+				Current: conflict.Current,
+			})
+		}
 		return c.Status(404).JSON(models.ErrorResponse{
 			Error: err.Error(),
 		})
 	}
 
+	setVersionETag(c, column.Version)
 	return c.JSON(column)
 }
 
-// DeleteColumn удаляет колонку
+// MoveColumn перемещает колонку на новую позицию
+func (h *BoardHandler) MoveColumn(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	columnID := c.Params("columnId")
+
+	var req models.MoveColumnRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if err := versionFromIfMatch(c, &req.Version); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if req.Order < 1 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Позиция колонки должна быть не меньше 1",
+		})
+	}
+
+	column, err := h.boardService.MoveColumn(actorFromCtx(c), boardID, columnID, req)
+	if err != nil {
+		if conflict := asConflict(err); conflict != nil {
+			return c.Status(409).JSON(models.ConflictResponse{
+				Error:   conflict.Error(),
+				Current: conflict.Current,
+			})
+		}
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	setVersionETag(c, column.Version)
+	return c.JSON(column)
+}
+
+// DeleteColumn перемещает колонку в корзину; режим для её карточек задается параметрами mode и target_column_id
 func (h *BoardHandler) DeleteColumn(c *fiber.Ctx) error {
 	boardID := c.Locals("board_id").(string)
 	columnID := c.Params("columnId")
 
-	err := h.boardService.DeleteColumn(boardID, columnID)
+	var query models.DeleteColumnQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверные параметры запроса",
+		})
+	}
+
+	if query.Mode != "" && !query.Mode.Valid() {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неизвестный режим удаления колонки: допустимы move, archive и delete",
+		})
+	}
+	if query.Mode == models.ColumnDeleteMove && query.TargetColumnID == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Для режима move укажите колонку target_column_id",
+		})
+	}
+
+	err := h.boardService.DeleteColumn(actorFromCtx(c), boardID, columnID, query)
+	if err != nil {
+		status := wipLimitStatus(err, 404)
+		if errors.Is(err, services.ErrColumnNotEmpty) {
+			status = 409
+		}
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Колонка перемещена в корзину",
+	})
+}
+
+// SetGuestAccess включает или отключает гостевой доступ к доске по паролю
+func (h *BoardHandler) SetGuestAccess(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.GuestAccessRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if err := h.boardService.SetGuestAccess(actorFromCtx(c), boardID, req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Настройки доступа обновлены",
+	})
+}
+
+// DeleteBoard удаляет текущую доску
+func (h *BoardHandler) DeleteBoard(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	if err := h.boardService.DeleteBoard(actorFromCtx(c), boardID); err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Доска удалена",
+	})
+}
+
+// ListTrash возвращает удаленные карточки и колонки текущей доски
+func (h *BoardHandler) ListTrash(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	trash, err := h.boardService.ListTrash(boardID)
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(trash)
+}
+
+// RestoreCard возвращает карточку из корзины
+func (h *BoardHandler) RestoreCard(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	cardID := c.Params("cardId")
+
+	card, err := h.boardService.RestoreCard(actorFromCtx(c), boardID, cardID)
+	if err != nil {
+		status := wipLimitStatus(err, 404)
+		if errors.Is(err, services.ErrColumnInTrash) {
+			status = 409
+		}
+		return c.Status(status).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(card)
+}
+
+// RestoreColumn возвращает колонку из корзины вместе с удаленными с ней карточками
+func (h *BoardHandler) RestoreColumn(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	columnID := c.Params("columnId")
+
+	column, err := h.boardService.RestoreColumn(actorFromCtx(c), boardID, columnID)
+	if err != nil {
+		return c.Status(wipLimitStatus(err, 404)).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(column)
+}
+
+// SetTrashRetention задает срок хранения корзины доски
+func (h *BoardHandler) SetTrashRetention(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.TrashRetentionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if err := h.boardService.SetTrashRetention(actorFromCtx(c), boardID, req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Срок хранения корзины обновлен",
+	})
+}
+
+// ListArchivedCards возвращает архивные карточки доски с поиском и курсорной пагинацией
+func (h *BoardHandler) ListArchivedCards(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var query models.ArchivedCardsQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверные параметры запроса",
+		})
+	}
+
+	page, err := h.boardService.ListArchivedCards(boardID, query)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(page)
+}
+
+// SearchCards ищет карточки доски по заголовку, описанию и комментариям
+func (h *BoardHandler) SearchCards(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var query models.CardSearchQuery
+	if err := c.QueryParser(&query); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверные параметры запроса",
+		})
+	}
+
+	page, err := h.boardService.SearchCards(boardID, query)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(page)
+}
+
+// ArchiveCard отправляет карточку в архив
+func (h *BoardHandler) ArchiveCard(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	cardID := c.Params("cardId")
+
+	card, err := h.boardService.ArchiveCard(actorFromCtx(c), boardID, cardID)
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(card)
+}
+
+// ArchiveColumnCards отправляет в архив все карточки колонки
+func (h *BoardHandler) ArchiveColumnCards(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	columnID := c.Params("columnId")
+
+	count, err := h.boardService.ArchiveColumnCards(actorFromCtx(c), boardID, columnID)
 	if err != nil {
 		return c.Status(404).JSON(models.ErrorResponse{
 			Error: err.Error(),
@@ -301,6 +829,170 @@ func (h *BoardHandler) DeleteColumn(c *fiber.Ctx) error {
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Колонка удалена",
+		"message":  "Карточки колонки отправлены в архив",
+		"archived": count,
 	})
 }
+
+// UnarchiveCard возвращает карточку из архива; в теле можно передать column_id
+func (h *BoardHandler) UnarchiveCard(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.UnarchiveCardsRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{
+				Error: "Неверный формат запроса",
+			})
+		}
+	}
+	req.CardIDs = []string{c.Params("cardId")}
+
+	cards, err := h.boardService.UnarchiveCards(actorFromCtx(c), boardID, req)
+	if err != nil {
+		return c.Status(unarchiveStatus(err)).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(cards[0])
+}
+
+// UnarchiveCards возвращает из архива несколько карточек
+func (h *BoardHandler) UnarchiveCards(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.UnarchiveCardsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if len(req.CardIDs) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Список карточек card_ids обязателен",
+		})
+	}
+
+	cards, err := h.boardService.UnarchiveCards(actorFromCtx(c), boardID, req)
+	if err != nil {
+		return c.Status(unarchiveStatus(err)).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(cards)
+}
+
+// unarchiveStatus выбирает код ответа для ошибки возврата из архива
+func unarchiveStatus(err error) int {
+	if errors.Is(err, services.ErrArchivedColumnGone) {
+		return 409
+	}
+	return wipLimitStatus(err, 404)
+}
+
+// validationStatus возвращает 400 для неверных значений пользовательских полей и fallback для остальных
+func validationStatus(err error, fallback int) int {
+	var invalid *services.ValidationError
+	if errors.As(err, &invalid) {
+		return 400
+	}
+	return fallback
+}
+
+// wipLimitStatus возвращает 409 для ошибки жесткого WIP-лимита и fallback для остальных
+func wipLimitStatus(err error, fallback int) int {
+	if errors.Is(err, services.ErrWIPLimitExceeded) {
+		return 409
+	}
+	return fallback
+}
+
+// SetWIPLimit задает WIP-лимит колонки
+func (h *BoardHandler) SetWIPLimit(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	columnID := c.Params("columnId")
+
+	var req models.WIPLimitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if err := versionFromIfMatch(c, &req.Version); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if req.Limit < 0 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "WIP-лимит не может быть отрицательным",
+		})
+	}
+
+	column, err := h.boardService.SetWIPLimit(actorFromCtx(c), boardID, columnID, req)
+	if err != nil {
+		if conflict := asConflict(err); conflict != nil {
+			return c.Status(409).JSON(models.ConflictResponse{
+				Error:   conflict.Error(),
+				Current: conflict.Current,
+			})
+		}
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	setVersionETag(c, column.Version)
+	return c.JSON(column)
+}
+
+// SetColumnSort задает порядок карточек колонки
+func (h *BoardHandler) SetColumnSort(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	columnID := c.Params("columnId")
+
+	var req models.ColumnSortRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if err := versionFromIfMatch(c, &req.Version); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	if !req.SortBy.Valid() {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неизвестный порядок сортировки",
+		})
+	}
+	if req.SortBy == models.ColumnSortField && req.SortFieldID == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Не указано поле для сортировки",
+		})
+	}
+
+	column, err := h.boardService.SetColumnSort(actorFromCtx(c), boardID, columnID, req)
+	if err != nil {
+		if conflict := asConflict(err); conflict != nil {
+			return c.Status(409).JSON(models.ConflictResponse{
+				Error:   conflict.Error(),
+				Current: conflict.Current,
+			})
+		}
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	setVersionETag(c, column.Version)
+	return c.JSON(column)
+}
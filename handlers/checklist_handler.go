@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ChecklistHandler struct {
+	checklistService *services.ChecklistService
+}
+
+func NewChecklistHandler(checklistService *services.ChecklistService) *ChecklistHandler {
+	return &ChecklistHandler{
+		checklistService: checklistService,
+	}
+}
+
+// List возвращает чек-листы карточки с пунктами
+func (h *ChecklistHandler) List(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	checklists, err := h.checklistService.List(boardID, c.Params("cardId"))
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(checklists)
+}
+
+// CreateChecklist добавляет чек-лист в карточку
+func (h *ChecklistHandler) CreateChecklist(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.ChecklistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	checklist, err := h.checklistService.CreateChecklist(actorFromCtx(c), boardID, c.Params("cardId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(checklist)
+}
+
+// UpdateChecklist переименовывает чек-лист
+func (h *ChecklistHandler) UpdateChecklist(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.ChecklistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	checklist, err := h.checklistService.UpdateChecklist(actorFromCtx(c), boardID,
+		c.Params("cardId"), c.Params("checklistId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(checklist)
+}
+
+// DeleteChecklist удаляет чек-лист
+func (h *ChecklistHandler) DeleteChecklist(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	err := h.checklistService.DeleteChecklist(actorFromCtx(c), boardID, c.Params("cardId"), c.Params("checklistId"))
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Чек-лист удален",
+	})
+}
+
+// CreateItem добавляет пункт в чек-лист
+func (h *ChecklistHandler) CreateItem(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.CreateChecklistItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	item, err := h.checklistService.CreateItem(actorFromCtx(c), boardID,
+		c.Params("cardId"), c.Params("checklistId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(item)
+}
+
+// UpdateItem изменяет пункт чек-листа
+func (h *ChecklistHandler) UpdateItem(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.UpdateChecklistItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	item, err := h.checklistService.UpdateItem(actorFromCtx(c), boardID,
+		c.Params("cardId"), c.Params("checklistId"), c.Params("itemId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(item)
+}
+
+// MoveItem перемещает пункт внутри чек-листа или в другой чек-лист карточки
+func (h *ChecklistHandler) MoveItem(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.MoveChecklistItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	item, err := h.checklistService.MoveItem(actorFromCtx(c), boardID,
+		c.Params("cardId"), c.Params("checklistId"), c.Params("itemId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(item)
+}
+
+// DeleteItem удаляет пункт чек-листа
+func (h *ChecklistHandler) DeleteItem(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	err := h.checklistService.DeleteItem(actorFromCtx(c), boardID,
+		c.Params("cardId"), c.Params("checklistId"), c.Params("itemId"))
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Пункт удален",
+	})
+}
+
+// ConvertItem превращает пункт чек-листа в карточку той же колонки
+func (h *ChecklistHandler) ConvertItem(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	card, err := h.checklistService.ConvertItem(actorFromCtx(c), boardID,
+		c.Params("cardId"), c.Params("checklistId"), c.Params("itemId"))
+	if err != nil {
+		if errors.Is(err, services.ErrWIPLimitExceeded) {
+			return c.Status(409).JSON(models.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	setVersionETag(c, card.Version)
+	return c.Status(201).JSON(card)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CommentHandler struct {
+	commentService *services.CommentService
+}
+
+func NewCommentHandler(commentService *services.CommentService) *CommentHandler {
+	return &CommentHandler{
+		commentService: commentService,
+	}
+}
+
+// commentError отвечает ошибкой сервиса комментариев с подходящим статусом
+func commentError(c *fiber.Ctx, err error, status int) error {
+	if errors.Is(err, services.ErrCommentForbidden) {
+		status = 403
+	}
+	return c.Status(status).JSON(models.ErrorResponse{
+		Error: err.Error(),
+	})
+}
+
+// List возвращает ветки комментариев карточки
+func (h *CommentHandler) List(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	comments, err := h.commentService.List(boardID, c.Params("cardId"))
+	if err != nil {
+		return commentError(c, err, 404)
+	}
+
+	return c.JSON(comments)
+}
+
+// Create добавляет комментарий к карточке
+func (h *CommentHandler) Create(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.CreateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	comment, err := h.commentService.Create(actorFromCtx(c), boardID, c.Params("cardId"), req)
+	if err != nil {
+		return commentError(c, err, 400)
+	}
+
+	return c.Status(201).JSON(comment)
+}
+
+// Update редактирует свой комментарий
+func (h *CommentHandler) Update(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.UpdateCommentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	comment, err := h.commentService.Update(actorFromCtx(c), boardID, c.Params("cardId"), c.Params("commentId"), req)
+	if err != nil {
+		return commentError(c, err, 400)
+	}
+
+	return c.JSON(comment)
+}
+
+// Delete удаляет комментарий
+func (h *CommentHandler) Delete(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	err := h.commentService.Delete(actorFromCtx(c), boardID, c.Params("cardId"), c.Params("commentId"))
+	if err != nil {
+		return commentError(c, err, 404)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Комментарий удален",
+	})
+}
+
+// History возвращает прежние версии текста комментария
+func (h *CommentHandler) History(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	revisions, err := h.commentService.History(boardID, c.Params("cardId"), c.Params("commentId"))
+	if err != nil {
+		return commentError(c, err, 404)
+	}
+
+	return c.JSON(revisions)
+}
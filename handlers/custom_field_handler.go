@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CustomFieldHandler struct {
+	customFieldService *services.CustomFieldService
+}
+
+func NewCustomFieldHandler(customFieldService *services.CustomFieldService) *CustomFieldHandler {
+	return &CustomFieldHandler{
+		customFieldService: customFieldService,
+	}
+}
+
+// List возвращает пользовательские поля текущей доски
+func (h *CustomFieldHandler) List(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	fields, err := h.customFieldService.List(boardID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fields)
+}
+
+// Create добавляет пользовательское поле на доску
+func (h *CustomFieldHandler) Create(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.CustomFieldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	field, err := h.customFieldService.Create(actorFromCtx(c), boardID, req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(field)
+}
+
+// Update переименовывает поле или меняет его варианты
+func (h *CustomFieldHandler) Update(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.UpdateCustomFieldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	field, err := h.customFieldService.Update(actorFromCtx(c), boardID, c.Params("fieldId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(field)
+}
+
+// Delete удаляет поле вместе с его значениями у карточек
+func (h *CustomFieldHandler) Delete(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	if err := h.customFieldService.Delete(actorFromCtx(c), boardID, c.Params("fieldId")); err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Поле удалено",
+	})
+}
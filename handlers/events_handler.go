@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"task-board/events"
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// heartbeatInterval — как часто отправлять комментарий SSE, чтобы прокси не закрывали соединение
+const heartbeatInterval = 25 * time.Second
+
+type EventsHandler struct {
+	broker      events.Broker
+	userService *services.UserService
+}
+
+func NewEventsHandler(broker events.Broker, userService *services.UserService) *EventsHandler {
+	return &EventsHandler{
+		broker:      broker,
+		userService: userService,
+	}
+}
+
+// hasAccess проверяет, что у подписчика по-прежнему есть доступ к доске.
+// При ошибке проверки доступ считается отозванным: клиент переподключится,
+// и права проверит middleware.
+func (h *EventsHandler) hasAccess(boardID, userID string) bool {
+	role, err := h.userService.BoardRole(boardID, userID)
+	if err != nil {
+		return false
+	}
+	return role.Allows(models.RoleViewer)
+}
+
+// Stream отправляет события текущей доски через Server-Sent Events
+func (h *EventsHandler) Stream(c *fiber.Ctx) error {
+	// Контекст Fiber нельзя использовать после выхода из обработчика,
+	// поэтому подписываемся заранее
+	boardID := c.Locals("board_id").(string)
+	userID, _ := c.Locals("user_id").(string)
+	ch, unsubscribe := h.broker.Subscribe(boardID)
+
+	// Поток живет дольше проверки прав в middleware, поэтому закрываем его,
+	// когда истекает токен
+	expiresAt, ok := c.Locals("token_expires_at").(time.Time)
+	if !ok {
+		expiresAt = time.Now().Add(24 * time.Hour)
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		expired := time.NewTimer(time.Until(expiresAt))
+		defer expired.Stop()
+
+		// Сообщаем клиенту, что подписка активна
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-ch:
+				if !ok {
+					return
+				}
+				// Смена гостевого доступа может лишить подписчика прав
+				if event.Type == events.BoardAccessChanged && !h.hasAccess(boardID, userID) {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+
+				// После удаления доски событий больше не будет: отправляем это событие и закрываем поток
+				if event.Type == events.BoardDeleted {
+					w.Flush()
+					return
+				}
+			case <-heartbeat.C:
+				// Роль могли отозвать или понизить, пока поток открыт
+				if !h.hasAccess(boardID, userID) {
+					return
+				}
+				fmt.Fprint(w, ": ping\n\n")
+			case <-expired.C:
+				return
+			}
+
+			// Ошибка записи означает, что клиент отключился
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type LabelHandler struct {
+	labelService *services.LabelService
+}
+
+func NewLabelHandler(labelService *services.LabelService) *LabelHandler {
+	return &LabelHandler{
+		labelService: labelService,
+	}
+}
+
+// List возвращает каталог меток текущей доски
+func (h *LabelHandler) List(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	labels, err := h.labelService.List(boardID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(labels)
+}
+
+// Create добавляет метку в каталог доски
+func (h *LabelHandler) Create(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.LabelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	label, err := h.labelService.Create(actorFromCtx(c), boardID, req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(label)
+}
+
+// Update меняет название и цвет метки
+func (h *LabelHandler) Update(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.LabelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	label, err := h.labelService.Update(actorFromCtx(c), boardID, c.Params("labelId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(label)
+}
+
+// Delete удаляет метку и снимает её со всех карточек
+func (h *LabelHandler) Delete(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	if err := h.labelService.Delete(actorFromCtx(c), boardID, c.Params("labelId")); err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Метка удалена",
+	})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SwimlaneHandler struct {
+	swimlaneService *services.SwimlaneService
+}
+
+func NewSwimlaneHandler(swimlaneService *services.SwimlaneService) *SwimlaneHandler {
+	return &SwimlaneHandler{
+		swimlaneService: swimlaneService,
+	}
+}
+
+// List возвращает дорожки текущей доски
+func (h *SwimlaneHandler) List(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	swimlanes, err := h.swimlaneService.List(boardID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(swimlanes)
+}
+
+// Create добавляет дорожку в конец доски
+func (h *SwimlaneHandler) Create(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.SwimlaneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	swimlane, err := h.swimlaneService.Create(actorFromCtx(c), boardID, req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(swimlane)
+}
+
+// Update переименовывает, сворачивает или разворачивает дорожку
+func (h *SwimlaneHandler) Update(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.UpdateSwimlaneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	swimlane, err := h.swimlaneService.Update(actorFromCtx(c), boardID, c.Params("swimlaneId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(swimlane)
+}
+
+// Move перемещает дорожку на новую позицию
+func (h *SwimlaneHandler) Move(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.MoveSwimlaneRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	swimlane, err := h.swimlaneService.Move(actorFromCtx(c), boardID, c.Params("swimlaneId"), req)
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(swimlane)
+}
+
+// Delete удаляет дорожку, оставляя её карточки на доске
+func (h *SwimlaneHandler) Delete(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	if err := h.swimlaneService.Delete(actorFromCtx(c), boardID, c.Params("swimlaneId")); err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Дорожка удалена",
+	})
+}
+
+// SetGrouping задает группировку карточек доски по дорожкам
+func (h *SwimlaneHandler) SetGrouping(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.SwimlaneGroupingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if err := h.swimlaneService.SetGrouping(actorFromCtx(c), boardID, req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Группировка дорожек обновлена",
+	})
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"task-board/middleware"
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UserHandler struct {
+	userService *services.UserService
+}
+
+func NewUserHandler(userService *services.UserService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+	}
+}
+
+// issueUserToken создает токен пользователя с актуальным списком его досок
+func issueUserToken(userService *services.UserService, userID, activeBoardID string) (string, error) {
+	boardIDs, err := userService.BoardIDs(userID)
+	if err != nil {
+		return "", err
+	}
+
+	return middleware.GenerateUserToken(userID, boardIDs, activeBoardID)
+}
+
+// userResponse собирает данные пользователя и его досок для ответа
+func (h *UserHandler) userResponse(user *models.User, activeBoardID string) (*models.UserResponse, error) {
+	boards, err := h.userService.ListBoards(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserResponse{
+		User:    *user,
+		Boards:  boards,
+		BoardID: activeBoardID,
+	}, nil
+}
+
+// Register регистрирует пользователя и сразу выполняет вход
+func (h *UserHandler) Register(c *fiber.Ctx) error {
+	var req models.RegisterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if req.Email == "" || req.Name == "" || req.Password == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Email, имя и пароль обязательны",
+		})
+	}
+
+	if len(req.Password) < 6 {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Пароль должен содержать минимум 6 символов",
+		})
+	}
+
+	user, err := h.userService.Register(req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	token, err := middleware.GenerateUserToken(user.ID, []string{}, "")
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: "Ошибка генерации токена",
+		})
+	}
+
+	setAuthCookie(c, token)
+
+	return c.Status(201).JSON(models.UserResponse{
+		User:   *user,
+		Boards: []models.BoardSummary{},
+	})
+}
+
+// Login вход пользователя по email и паролю
+func (h *UserHandler) Login(c *fiber.Ctx) error {
+	var req models.UserLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	user, err := h.userService.Authenticate(req.Email, req.Password)
+	if err != nil {
+		return c.Status(401).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	response, err := h.userResponse(user, "")
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Если доска одна, сразу делаем её активной
+	if len(response.Boards) == 1 {
+		response.BoardID = response.Boards[0].ID
+	}
+
+	token, err := issueUserToken(h.userService, user.ID, response.BoardID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: "Ошибка генерации токена",
+		})
+	}
+
+	setAuthCookie(c, token)
+
+	return c.JSON(response)
+}
+
+// Me возвращает текущего пользователя и его доски
+func (h *UserHandler) Me(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	if userID == "" {
+		return c.Status(403).JSON(models.ErrorResponse{
+			Error: "Требуется учетная запись пользователя",
+		})
+	}
+
+	user, err := h.userService.GetUser(userID)
+	if err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	response, err := h.userResponse(user, c.Locals("board_id").(string))
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	// Роль на активной доске
+	if response.BoardID != "" {
+		role, err := h.userService.BoardRole(response.BoardID, userID)
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{
+				Error: err.Error(),
+			})
+		}
+		response.Role = role
+	}
+
+	return c.JSON(response)
+}
+
+// OpenBoard делает доску активной для текущего пользователя
+func (h *UserHandler) OpenBoard(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	boardID := c.Params("id")
+
+	if userID == "" {
+		return c.Status(403).JSON(models.ErrorResponse{
+			Error: "Требуется учетная запись пользователя",
+		})
+	}
+
+	isMember, err := h.userService.IsMember(boardID, userID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+	if !isMember {
+		return c.Status(403).JSON(models.ErrorResponse{
+			Error: "Нет доступа к доске",
+		})
+	}
+
+	token, err := issueUserToken(h.userService, userID, boardID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: "Ошибка генерации токена",
+		})
+	}
+
+	setAuthCookie(c, token)
+
+	return c.JSON(models.LoginResponse{
+		Message: "Доска открыта",
+		BoardID: boardID,
+	})
+}
+
+// ClaimBoard назначает текущего пользователя владельцем доски без владельца
+// по паролю доски
+func (h *UserHandler) ClaimBoard(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	boardID := c.Params("id")
+
+	if userID == "" {
+		return c.Status(403).JSON(models.ErrorResponse{
+			Error: "Требуется учетная запись пользователя",
+		})
+	}
+
+	var req models.LoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if _, err := h.userService.ClaimBoard(boardID, userID, req.Password); err != nil {
+		return c.Status(403).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	token, err := issueUserToken(h.userService, userID, boardID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: "Ошибка генерации токена",
+		})
+	}
+
+	setAuthCookie(c, token)
+
+	return c.JSON(models.LoginResponse{
+		Message: "Вы стали владельцем доски",
+		BoardID: boardID,
+	})
+}
+
+// ListMembers возвращает участников текущей доски
+func (h *UserHandler) ListMembers(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	members, err := h.userService.ListMembers(boardID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(members)
+}
+
+// AddMember добавляет пользователя в участники текущей доски
+func (h *UserHandler) AddMember(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.AddMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	if req.Email == "" {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Email обязателен",
+		})
+	}
+
+	member, err := h.userService.AddMember(boardID, req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(member)
+}
+
+// UpdateMember меняет роль участника текущей доски
+func (h *UserHandler) UpdateMember(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	userID := c.Params("userId")
+
+	var req models.UpdateMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	member, err := h.userService.UpdateMemberRole(boardID, userID, req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(member)
+}
+
+// RemoveMember удаляет пользователя из участников текущей доски
+func (h *UserHandler) RemoveMember(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+	userID := c.Params("userId")
+
+	if err := h.userService.RemoveMember(boardID, userID); err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Участник удален",
+	})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"task-board/models"
+	"task-board/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ViewHandler struct {
+	viewService *services.ViewService
+}
+
+func NewViewHandler(viewService *services.ViewService) *ViewHandler {
+	return &ViewHandler{
+		viewService: viewService,
+	}
+}
+
+// List возвращает сохраненные представления текущей доски
+func (h *ViewHandler) List(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	views, err := h.viewService.List(boardID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(views)
+}
+
+// Create сохраняет фильтр доски как представление
+func (h *ViewHandler) Create(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.SavedViewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	view, err := h.viewService.Create(actorFromCtx(c), boardID, req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.Status(201).JSON(view)
+}
+
+// Update меняет название и фильтр представления
+func (h *ViewHandler) Update(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	var req models.SavedViewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: "Неверный формат запроса",
+		})
+	}
+
+	view, err := h.viewService.Update(actorFromCtx(c), boardID, c.Params("viewId"), req)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(view)
+}
+
+// Delete удаляет представление
+func (h *ViewHandler) Delete(c *fiber.Ctx) error {
+	boardID := c.Locals("board_id").(string)
+
+	if err := h.viewService.Delete(actorFromCtx(c), boardID, c.Params("viewId")); err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{
+			Error: err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Представление удалено",
+	})
+}
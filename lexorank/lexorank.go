@@ -0,0 +1,107 @@
+// Package lexorank генерирует строковые ранги для упорядочивания карточек и колонок.
+//
+// Ранг — это дробь в системе счисления по основанию 36, записанная цифрами 0-9a-z
+// без ведущего "0." и без завершающих нулей. Такие строки сравниваются побайтово
+// так же, как соответствующие дроби, поэтому между любыми двумя рангами всегда
+// можно вставить новый, не меняя соседей.
+package lexorank
+
+import "strings"
+
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// MaxLength — длина ранга, после которой ранги колонки или доски стоит перебалансировать
+const MaxLength = 32
+
+// Between возвращает ранг строго между prev и next.
+// Пустой prev означает начало списка, пустой next — конец списка.
+func Between(prev, next string) string {
+	return midpoint(prev, next)
+}
+
+// Spread возвращает n равномерно распределенных рангов одинаковой длины
+func Spread(n int) []string {
+	if n <= 0 {
+		return []string{}
+	}
+
+	// Подбираем длину, при которой между соседними рангами остается запас
+	width := 1
+	capacity := len(digits)
+	for capacity < (n+1)*len(digits) {
+		width++
+		capacity *= len(digits)
+	}
+
+	step := capacity / (n + 1)
+	ranks := make([]string, n)
+	for i := range ranks {
+		ranks[i] = encode((i+1)*step, width)
+	}
+	return ranks
+}
+
+// NeedsRebalance сообщает, что ранг стал слишком длинным
+func NeedsRebalance(rank string) bool {
+	return len(rank) > MaxLength
+}
+
+// midpoint реализует поиск середины между двумя дробями, записанными цифрами digits.
+// Предполагается, что prev < next (или next пустой) и ни одна строка не оканчивается на "0".
+func midpoint(prev, next string) string {
+	if next != "" {
+		// Отбрасываем общий префикс
+		n := 0
+		for n < len(next) && digitAt(prev, n) == next[n] {
+			n++
+		}
+		if n > 0 {
+			return next[:n] + midpoint(suffix(prev, n), next[n:])
+		}
+	}
+
+	// Первые цифры различаются (или одной из строк нет)
+	digitPrev := 0
+	if prev != "" {
+		digitPrev = strings.IndexByte(digits, prev[0])
+	}
+	digitNext := len(digits)
+	if next != "" {
+		digitNext = strings.IndexByte(digits, next[0])
+	}
+
+	if digitNext-digitPrev > 1 {
+		return string(digits[(digitPrev+digitNext+1)/2])
+	}
+
+	// Первые цифры соседние
+	if len(next) > 1 {
+		return next[:1]
+	}
+	return string(digits[digitPrev]) + midpoint(suffix(prev, 1), "")
+}
+
+// digitAt возвращает цифру строки в позиции i, дополняя строку нулями справа
+func digitAt(s string, i int) byte {
+	if i < len(s) {
+		return s[i]
+	}
+	return digits[0]
+}
+
+func suffix(s string, n int) string {
+	if n >= len(s) {
+		return ""
+	}
+	return s[n:]
+}
+
+// encode записывает value фиксированным числом цифр и убирает завершающие нули
+func encode(value, width int) string {
+	buf := make([]byte, width)
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = digits[value%len(digits)]
+		value /= len(digits)
+	}
+	return strings.TrimRight(string(buf), digits[:1])
+}
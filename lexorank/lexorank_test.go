@@ -0,0 +1,155 @@
+package lexorank
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next string
+		want       string
+	}{
+		{"пустой список", "", "", "i"},
+		{"начало списка", "", "i", "9"},
+		{"конец списка", "i", "", "r"},
+		{"перед первой цифрой", "", "1", "0i"},
+		{"соседние ранги", "a", "b", "ai"},
+		{"соседние ранги с продолжением", "az", "b", "azi"},
+		{"общий префикс", "a", "ab", "a6"},
+		{"next длиннее на одну цифру", "a", "b5", "b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Between(tt.prev, tt.next)
+			if got != tt.want {
+				t.Fatalf("Between(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+			}
+			checkOrder(t, tt.prev, got, tt.next)
+		})
+	}
+}
+
+func TestBetweenKeepsOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		insert func(ranks []string) (int, string)
+	}{
+		{"в начало", func(ranks []string) (int, string) {
+			return 0, Between("", ranks[0])
+		}},
+		{"в конец", func(ranks []string) (int, string) {
+			return len(ranks), Between(ranks[len(ranks)-1], "")
+		}},
+		{"после первого", func(ranks []string) (int, string) {
+			return 1, Between(ranks[0], ranks[1])
+		}},
+		{"перед последним", func(ranks []string) (int, string) {
+			return len(ranks) - 1, Between(ranks[len(ranks)-2], ranks[len(ranks)-1])
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranks := Spread(2)
+			for i := 0; i < 200; i++ {
+				pos, rank := tt.insert(ranks)
+				ranks = append(ranks[:pos], append([]string{rank}, ranks[pos:]...)...)
+			}
+			for i := 1; i < len(ranks); i++ {
+				if ranks[i-1] >= ranks[i] {
+					t.Fatalf("ранги не упорядочены: %q >= %q", ranks[i-1], ranks[i])
+				}
+			}
+			for _, rank := range ranks {
+				if strings.HasSuffix(rank, "0") {
+					t.Fatalf("ранг %q оканчивается нулем", rank)
+				}
+			}
+		})
+	}
+}
+
+func TestBetweenReachesMaxLength(t *testing.T) {
+	// Вставка перед одним и тем же соседом удлиняет ранг, пока не потребуется перебалансировка
+	prev, next := "a", "b"
+	for i := 0; i < 10*MaxLength && !NeedsRebalance(next); i++ {
+		next = Between(prev, next)
+		checkOrder(t, prev, next, "b")
+	}
+	if !NeedsRebalance(next) {
+		t.Fatalf("ранг %q так и не превысил MaxLength", next)
+	}
+}
+
+func TestNeedsRebalance(t *testing.T) {
+	tests := []struct {
+		rank string
+		want bool
+	}{
+		{"", false},
+		{"i", false},
+		{strings.Repeat("i", MaxLength), false},
+		{strings.Repeat("i", MaxLength+1), true},
+	}
+
+	for _, tt := range tests {
+		if got := NeedsRebalance(tt.rank); got != tt.want {
+			t.Errorf("NeedsRebalance(%d символов) = %v, want %v", len(tt.rank), got, tt.want)
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{-1, []string{}},
+		{0, []string{}},
+		{1, []string{"i"}},
+		{35, nil},
+		{36, nil},
+		{1000, nil},
+	}
+
+	for _, tt := range tests {
+		ranks := Spread(tt.n)
+		if tt.want != nil && strings.Join(ranks, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Spread(%d) = %q, want %q", tt.n, ranks, tt.want)
+		}
+		if tt.n > 0 && len(ranks) != tt.n {
+			t.Fatalf("Spread(%d) вернул %d рангов", tt.n, len(ranks))
+		}
+		for i := range ranks {
+			prev := ""
+			if i > 0 {
+				prev = ranks[i-1]
+			}
+			checkOrder(t, prev, ranks[i], "")
+			if NeedsRebalance(ranks[i]) {
+				t.Errorf("Spread(%d): ранг %q требует перебалансировки", tt.n, ranks[i])
+			}
+		}
+		// Между соседними рангами остается место для вставки
+		for i := 1; i < len(ranks); i++ {
+			checkOrder(t, ranks[i-1], Between(ranks[i-1], ranks[i]), ranks[i])
+		}
+	}
+}
+
+// checkOrder проверяет, что prev < rank < next; пустые границы не ограничивают ранг
+func checkOrder(t *testing.T, prev, rank, next string) {
+	t.Helper()
+	if rank == "" || strings.HasSuffix(rank, "0") {
+		t.Fatalf("недопустимый ранг %q", rank)
+	}
+	if prev != "" && prev >= rank {
+		t.Fatalf("ранг %q не больше %q", rank, prev)
+	}
+	if next != "" && rank >= next {
+		t.Fatalf("ранг %q не меньше %q", rank, next)
+	}
+}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"log"
+	"os"
+	"time"
 
 	"task-board/database"
+	"task-board/events"
 	"task-board/handlers"
 	"task-board/middleware"
+	"task-board/models"
 	"task-board/services"
+	"task-board/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -31,8 +36,12 @@ func main() {
 		log.Fatal("Ошибка миграции моделей:", err)
 	}
 
+	// Ограничения вложений; тело запроса должно вмещать самый большой файл
+	attachmentConfig := services.GetAttachmentConfigFromEnv()
+
 	// Создаем экземпляр Fiber
 	app := fiber.New(fiber.Config{
+		BodyLimit: int(attachmentConfig.MaxFileSize) + 1<<20,
 		ErrorHandler: func(ctx *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -49,37 +58,205 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000",
 		AllowCredentials: true,
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Creation-Key, If-Match",
 		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
+		ExposeHeaders:    "ETag",
 	}))
 
+	// Брокер событий: в памяти процесса или через PostgreSQL LISTEN/NOTIFY для нескольких экземпляров
+	var broker events.Broker
+	if os.Getenv("EVENTS_BROKER") == "postgres" {
+		broker = events.NewPostgresBroker(database.DB, database.GetConfigFromEnv().DSN())
+	} else {
+		broker = events.NewMemoryBroker()
+	}
+
+	// Хранилище файлов вложений: локальный каталог или S3-совместимое хранилище
+	var store storage.Storage
+	if os.Getenv("STORAGE_BACKEND") == "s3" {
+		s3Store, err := storage.NewS3Storage(storage.GetS3ConfigFromEnv())
+		if err != nil {
+			log.Fatal("Ошибка настройки хранилища S3:", err)
+		}
+		store = s3Store
+	} else {
+		localStore, err := storage.NewLocalStorage(getEnv("STORAGE_PATH", "./uploads"))
+		if err != nil {
+			log.Fatal("Ошибка настройки локального хранилища:", err)
+		}
+		store = localStore
+	}
+
 	// Сервисы
-	boardService := services.NewBoardService()
-	boardHandler := handlers.NewBoardHandler(boardService)
+	boardService := services.NewBoardService(broker, store)
+	boardService.StartRankRebalancer(getEnvDuration("RANK_REBALANCE_INTERVAL", 10*time.Minute))
+	boardService.StartTrashPurger(getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour))
+	userService := services.NewUserService()
+	boardHandler := handlers.NewBoardHandler(boardService, userService)
+	userHandler := handlers.NewUserHandler(userService)
+	eventsHandler := handlers.NewEventsHandler(broker, userService)
+	activityHandler := handlers.NewActivityHandler(services.NewActivityService())
+	commentHandler := handlers.NewCommentHandler(services.NewCommentService(broker))
+	labelHandler := handlers.NewLabelHandler(services.NewLabelService(broker))
+	customFieldHandler := handlers.NewCustomFieldHandler(services.NewCustomFieldService(broker))
+	viewHandler := handlers.NewViewHandler(services.NewViewService(broker))
+	swimlaneHandler := handlers.NewSwimlaneHandler(services.NewSwimlaneService(broker))
+	checklistHandler := handlers.NewChecklistHandler(services.NewChecklistService(broker))
+	attachmentHandler := handlers.NewAttachmentHandler(services.NewAttachmentService(broker, store, attachmentConfig))
 
 	// Публичные маршруты
 	api := app.Group("/api")
 
+	// Создание доски (с защитой от злоупотреблений)
+	creationConfig := middleware.GetBoardCreationConfigFromEnv()
+	creationLimiter := middleware.BoardCreationLimiter(creationConfig)
+	api.Post("/boards",
+		middleware.OptionalAuthMiddleware(),
+		middleware.BoardCreationGuard(creationConfig),
+		creationLimiter,
+		boardHandler.CreateBoard,
+	)
+
+	// Импорт доски из документа экспорта и из Trello (те же ограничения, что у создания доски)
+	api.Post("/boards/import",
+		middleware.OptionalAuthMiddleware(),
+		middleware.BoardCreationGuard(creationConfig),
+		creationLimiter,
+		boardHandler.ImportBoard,
+	)
+	// Предпросмотр импорта из Trello (dry_run=true) доску не создает и квоту не расходует
+	api.Post("/boards/import/trello",
+		middleware.OptionalAuthMiddleware(),
+		middleware.BoardCreationGuard(creationConfig),
+		middleware.SkipOnDryRun(creationLimiter),
+		boardHandler.ImportTrello,
+	)
 
-	// Вход в доску
+	// Гостевой вход в доску по паролю
 	api.Post("/boards/:id/login", boardHandler.Login)
 
+	// Учетные записи пользователей
+	api.Post("/auth/register", userHandler.Register)
+	api.Post("/auth/login", userHandler.Login)
+
 	// Защищенные маршруты (требуют аутентификации)
 	protected := api.Use(middleware.AuthMiddleware())
 
+	// Политики доступа к текущей доске
+	viewer := middleware.RequireRole(userService, models.RoleViewer)
+	commenter := middleware.RequireRole(userService, models.RoleCommenter)
+	editor := middleware.RequireRole(userService, models.RoleEditor)
+	owner := middleware.RequireRole(userService, models.RoleOwner)
+
+	// Текущий пользователь и выбор активной доски
+	protected.Get("/me", userHandler.Me)
+	protected.Post("/boards/:id/open", userHandler.OpenBoard)
+	protected.Post("/boards/:id/claim", userHandler.ClaimBoard)
+
 	// Получение данных доски
-	protected.Get("/board", boardHandler.GetBoard)
+	protected.Get("/board", viewer, boardHandler.GetBoard)
+
+	// Изменения доски в реальном времени (Server-Sent Events)
+	protected.Get("/board/events", viewer, eventsHandler.Stream)
+
+	// Журнал изменений доски
+	protected.Get("/board/activity", viewer, activityHandler.List)
+	protected.Get("/cards/:cardId/activity", viewer, activityHandler.CardHistory)
+
+	// Доступ к доске
+	protected.Put("/board/guest-access", owner, boardHandler.SetGuestAccess)
+	protected.Delete("/board", owner, boardHandler.DeleteBoard)
+	protected.Get("/board/members", viewer, userHandler.ListMembers)
+	protected.Post("/board/members", owner, userHandler.AddMember)
+	protected.Put("/board/members/:userId", owner, userHandler.UpdateMember)
+	protected.Delete("/board/members/:userId", owner, userHandler.RemoveMember)
+
+	// Корзина удаленных карточек и колонок
+	protected.Get("/board/trash", viewer, boardHandler.ListTrash)
+	protected.Put("/board/trash-retention", owner, boardHandler.SetTrashRetention)
+	protected.Post("/columns/:columnId/restore", owner, boardHandler.RestoreColumn)
+	protected.Post("/cards/:cardId/restore", editor, boardHandler.RestoreCard)
+
+	// Экспорт доски в JSON
+	protected.Get("/board/export", viewer, boardHandler.ExportBoard)
+
+	// Карточки доски в CSV
+	protected.Get("/board/export/csv", viewer, boardHandler.ExportCardsCSV)
+	protected.Post("/board/import/csv", editor, boardHandler.ImportCardsCSV)
+
+	// Полнотекстовый поиск карточек
+	protected.Get("/board/search", viewer, boardHandler.SearchCards)
+
+	// Архив карточек
+	protected.Get("/board/archive", viewer, boardHandler.ListArchivedCards)
+	protected.Post("/columns/:columnId/archive-cards", editor, boardHandler.ArchiveColumnCards)
+	protected.Post("/cards/unarchive", editor, boardHandler.UnarchiveCards)
+	protected.Post("/cards/:cardId/archive", editor, boardHandler.ArchiveCard)
+	protected.Post("/cards/:cardId/unarchive", editor, boardHandler.UnarchiveCard)
 
 	// Работа с колонками
-	protected.Post("/columns", boardHandler.CreateColumn)
-	protected.Put("/columns/:columnId", boardHandler.UpdateColumn)
-	protected.Delete("/columns/:columnId", boardHandler.DeleteColumn)
+	protected.Post("/columns", editor, boardHandler.CreateColumn)
+	protected.Put("/columns/:columnId", editor, boardHandler.UpdateColumn)
+	protected.Put("/columns/:columnId/move", editor, boardHandler.MoveColumn)
+	protected.Put("/columns/:columnId/wip-limit", editor, boardHandler.SetWIPLimit)
+	protected.Put("/columns/:columnId/sort", editor, boardHandler.SetColumnSort)
+	protected.Delete("/columns/:columnId", owner, boardHandler.DeleteColumn)
 
 	// Работа с карточками
-	protected.Post("/cards", boardHandler.CreateCard)
-	protected.Put("/cards/:cardId", boardHandler.UpdateCard)
-	protected.Put("/cards/:cardId/move", boardHandler.MoveCard)
-	protected.Delete("/cards/:cardId", boardHandler.DeleteCard)
+	protected.Post("/cards", editor, boardHandler.CreateCard)
+	protected.Put("/cards/:cardId", editor, boardHandler.UpdateCard)
+	protected.Put("/cards/:cardId/move", editor, boardHandler.MoveCard)
+	protected.Delete("/cards/:cardId", editor, boardHandler.DeleteCard)
+
+	// Дорожки доски
+	protected.Get("/swimlanes", viewer, swimlaneHandler.List)
+	protected.Post("/swimlanes", editor, swimlaneHandler.Create)
+	protected.Put("/swimlanes/:swimlaneId", editor, swimlaneHandler.Update)
+	protected.Put("/swimlanes/:swimlaneId/move", editor, swimlaneHandler.Move)
+	protected.Delete("/swimlanes/:swimlaneId", editor, swimlaneHandler.Delete)
+	protected.Put("/board/swimlane-grouping", owner, swimlaneHandler.SetGrouping)
+
+	// Каталог меток доски
+	protected.Get("/labels", viewer, labelHandler.List)
+	protected.Post("/labels", editor, labelHandler.Create)
+	protected.Put("/labels/:labelId", editor, labelHandler.Update)
+	protected.Delete("/labels/:labelId", editor, labelHandler.Delete)
+
+	// Сохраненные представления доски
+	protected.Get("/views", viewer, viewHandler.List)
+	protected.Post("/views", editor, viewHandler.Create)
+	protected.Put("/views/:viewId", editor, viewHandler.Update)
+	protected.Delete("/views/:viewId", editor, viewHandler.Delete)
+
+	// Пользовательские поля карточек
+	protected.Get("/custom-fields", viewer, customFieldHandler.List)
+	protected.Post("/custom-fields", editor, customFieldHandler.Create)
+	protected.Put("/custom-fields/:fieldId", editor, customFieldHandler.Update)
+	protected.Delete("/custom-fields/:fieldId", owner, customFieldHandler.Delete)
+
+	// Чек-листы карточек
+	protected.Get("/cards/:cardId/checklists", viewer, checklistHandler.List)
+	protected.Post("/cards/:cardId/checklists", editor, checklistHandler.CreateChecklist)
+	protected.Put("/cards/:cardId/checklists/:checklistId", editor, checklistHandler.UpdateChecklist)
+	protected.Delete("/cards/:cardId/checklists/:checklistId", editor, checklistHandler.DeleteChecklist)
+	protected.Post("/cards/:cardId/checklists/:checklistId/items", editor, checklistHandler.CreateItem)
+	protected.Put("/cards/:cardId/checklists/:checklistId/items/:itemId", editor, checklistHandler.UpdateItem)
+	protected.Put("/cards/:cardId/checklists/:checklistId/items/:itemId/move", editor, checklistHandler.MoveItem)
+	protected.Delete("/cards/:cardId/checklists/:checklistId/items/:itemId", editor, checklistHandler.DeleteItem)
+	protected.Post("/cards/:cardId/checklists/:checklistId/items/:itemId/convert", editor, checklistHandler.ConvertItem)
+
+	// Вложения карточек
+	protected.Get("/cards/:cardId/attachments", viewer, attachmentHandler.List)
+	protected.Post("/cards/:cardId/attachments", editor, attachmentHandler.Upload)
+	protected.Get("/cards/:cardId/attachments/:attachmentId", viewer, attachmentHandler.Download)
+	protected.Delete("/cards/:cardId/attachments/:attachmentId", editor, attachmentHandler.Delete)
+
+	// Комментарии к карточкам
+	protected.Get("/cards/:cardId/comments", viewer, commentHandler.List)
+	protected.Post("/cards/:cardId/comments", commenter, commentHandler.Create)
+	protected.Put("/cards/:cardId/comments/:commentId", commenter, commentHandler.Update)
+	protected.Delete("/cards/:cardId/comments/:commentId", commenter, commentHandler.Delete)
+	protected.Get("/cards/:cardId/comments/:commentId/history", viewer, commentHandler.History)
 
 	// Выход
 	protected.Post("/logout", boardHandler.Logout)
@@ -91,3 +268,17 @@ func main() {
 	log.Println("Сервер запущен на порту :3000")
 	log.Fatal(app.Listen(":3000"))
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -9,50 +9,100 @@ import (
 
 var jwtSecret = []byte("your-secret-key-change-in-production")
 
+// Claims содержит данные токена. Для гостевого входа по паролю доски
+// UserID пустой, а BoardIDs содержит только BoardID.
 type Claims struct {
-	BoardID string `json:"board_id"`
+	UserID   string   `json:"user_id,omitempty"`
+	BoardID  string   `json:"board_id"`
+	BoardIDs []string `json:"board_ids"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken создает JWT токен для доступа к доске
+// GenerateToken создает JWT токен гостевого доступа к доске
 func GenerateToken(boardID string) (string, error) {
-	claims := Claims{
-		BoardID: boardID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	return signClaims(Claims{
+		BoardID:  boardID,
+		BoardIDs: []string{boardID},
+	})
+}
+
+// GenerateUserToken создает JWT токен пользователя со списком доступных ему досок
+func GenerateUserToken(userID string, boardIDs []string, activeBoardID string) (string, error) {
+	return signClaims(Claims{
+		UserID:   userID,
+		BoardID:  activeBoardID,
+		BoardIDs: boardIDs,
+	})
+}
+
+func signClaims(claims Claims) (string, error) {
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtSecret)
 }
 
+// parseToken извлекает и проверяет токен из HTTP-only cookie
+func parseToken(c *fiber.Ctx) (*Claims, bool) {
+	tokenString := c.Cookies("auth_token")
+	if tokenString == "" {
+		return nil, false
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, false
+	}
+
+	return claims, true
+}
+
+// storeClaims сохраняет данные токена в контексте запроса
+func storeClaims(c *fiber.Ctx, claims *Claims) {
+	c.Locals("user_id", claims.UserID)
+	c.Locals("board_id", claims.BoardID)
+	c.Locals("board_ids", claims.BoardIDs)
+	if claims.ExpiresAt != nil {
+		c.Locals("token_expires_at", claims.ExpiresAt.Time)
+	}
+}
+
 // AuthMiddleware проверяет JWT токен из HTTP-only cookie
 func AuthMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Получаем токен из HTTP-only cookie
-		tokenString := c.Cookies("auth_token")
-		if tokenString == "" {
+		if c.Cookies("auth_token") == "" {
 			return c.Status(401).JSON(fiber.Map{
 				"error": "Требуется авторизация",
 			})
 		}
 
 		// Парсим и валидируем токен
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, ok := parseToken(c)
+		if !ok {
 			return c.Status(401).JSON(fiber.Map{
 				"error": "Недействительный токен",
 			})
 		}
 
-		// Сохраняем board_id в контексте
-		c.Locals("board_id", claims.BoardID)
+		// Сохраняем user_id и board_id в контексте
+		storeClaims(c, claims)
+		return c.Next()
+	}
+}
+
+// OptionalAuthMiddleware сохраняет данные токена, если он есть, но не требует авторизации
+func OptionalAuthMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if claims, ok := parseToken(c); ok {
+			storeClaims(c, claims)
+		}
 		return c.Next()
 	}
 }
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// BoardCreationConfig содержит параметры защиты от злоупотреблений при создании досок
+type BoardCreationConfig struct {
+	Enabled  bool
+	Key      string
+	PerIP    int
+	Interval time.Duration
+}
+
+// GetBoardCreationConfigFromEnv получает настройки создания досок из переменных окружения
+func GetBoardCreationConfigFromEnv() BoardCreationConfig {
+	return BoardCreationConfig{
+		Enabled:  getEnvBool("BOARD_CREATION_ENABLED", true),
+		Key:      os.Getenv("BOARD_CREATION_KEY"),
+		PerIP:    getEnvInt("BOARD_CREATION_PER_IP", 5),
+		Interval: getEnvDuration("BOARD_CREATION_INTERVAL", time.Hour),
+	}
+}
+
+// BoardCreationGuard проверяет, что создание досок включено и передан верный ключ создания (если он задан)
+func BoardCreationGuard(config BoardCreationConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !config.Enabled {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Создание досок отключено",
+			})
+		}
+
+		if config.Key != "" {
+			key := c.Get("X-Creation-Key")
+			if subtle.ConstantTimeCompare([]byte(key), []byte(config.Key)) != 1 {
+				return c.Status(403).JSON(fiber.Map{
+					"error": "Неверный ключ создания доски",
+				})
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// BoardCreationLimiter ограничивает количество досок, создаваемых с одного IP-адреса
+func BoardCreationLimiter(config BoardCreationConfig) fiber.Handler {
+	if config.PerIP <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	// Квота учитывает только успешно созданные доски
+	return limiter.New(limiter.Config{
+		Max:                config.PerIP,
+		Expiration:         config.Interval,
+		SkipFailedRequests: true,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(429).JSON(fiber.Map{
+				"error": "Превышен лимит создания досок, попробуйте позже",
+			})
+		},
+	})
+}
+
+// SkipOnDryRun пропускает handler для предпросмотра (dry_run=true): такой запрос
+// ничего не создает и не должен расходовать квоту создания досок
+func SkipOnDryRun(handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Параметр разбирается так же, как в обработчике, чтобы не расходиться с ним
+		var q struct {
+			DryRun bool `query:"dry_run"`
+		}
+		if err := c.QueryParser(&q); err == nil && q.DryRun {
+			return c.Next()
+		}
+		return handler(c)
+	}
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"task-board/models"
+)
+
+// RoleResolver определяет роль пользователя на доске.
+// Пустая роль означает отсутствие доступа, пустой userID — гостевой вход.
+type RoleResolver interface {
+	BoardRole(boardID, userID string) (models.Role, error)
+}
+
+// RequireRole пропускает запрос, только если роль на текущей доске не ниже требуемой.
+// Должен подключаться после AuthMiddleware.
+func RequireRole(resolver RoleResolver, required models.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		boardID, _ := c.Locals("board_id").(string)
+		userID, _ := c.Locals("user_id").(string)
+
+		if boardID == "" {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Доска не выбрана",
+			})
+		}
+
+		role, err := resolver.BoardRole(boardID, userID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if role == "" {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Нет доступа к доске",
+			})
+		}
+
+		if !role.Allows(required) {
+			return c.Status(403).JSON(fiber.Map{
+				"error": "Недостаточно прав для этого действия",
+			})
+		}
+
+		// Сохраняем роль в контексте
+		c.Locals("role", role)
+		return c.Next()
+	}
+}
@@ -1,53 +1,485 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"time"
+
+	"gorm.io/gorm"
 )
 
-// Board представляет доску задач
+// Board представляет доску задач. TrashRetentionDays — сколько дней
+// удаленные карточки и колонки хранятся в корзине. SwimlaneGrouping задает,
+// как карточки делятся на горизонтальные дорожки.
 type Board struct {
+	ID                 string           `json:"id" gorm:"primaryKey;size:32"`
+	Name               string           `json:"name" gorm:"not null;size:255"`
+	PasswordHash       string           `json:"-" gorm:"not null;size:255"`
+	GuestAccess        bool             `json:"guest_access" gorm:"not null;default:true"`
+	GuestRole          Role             `json:"guest_role" gorm:"not null;size:20;default:editor"`
+	TrashRetentionDays int              `json:"trash_retention_days" gorm:"not null;default:30"`
+	SwimlaneGrouping   SwimlaneGrouping `json:"swimlane_grouping" gorm:"not null;size:20;default:''"`
+	CreatedAt          time.Time        `json:"created" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time        `json:"updated" gorm:"autoUpdateTime"`
+
+	// Связь с колонками
+	Columns []Column `json:"columns" gorm:"foreignKey:BoardID"`
+
+	// Пользовательские поля карточек доски
+	CustomFields []CustomField `json:"custom_fields" gorm:"foreignKey:BoardID"`
+
+	// Вычисляемое поле для ответа доски: карточки, разложенные по дорожкам и колонкам
+	Lanes []Lane `json:"lanes" gorm:"-"`
+}
+
+// SwimlaneGrouping определяет, по какому признаку карточки доски делятся на дорожки
+type SwimlaneGrouping string
+
+const (
+	SwimlaneGroupingManual   SwimlaneGrouping = ""         // дорожки доски, назначенные карточкам вручную
+	SwimlaneGroupingAssignee SwimlaneGrouping = "assignee" // по ответственному
+	SwimlaneGroupingLabel    SwimlaneGrouping = "label"    // по меткам
+)
+
+// Valid проверяет, что способ группировки известен
+func (g SwimlaneGrouping) Valid() bool {
+	return g == SwimlaneGroupingManual || g == SwimlaneGroupingAssignee || g == SwimlaneGroupingLabel
+}
+
+// Role определяет уровень доступа к доске
+type Role string
+
+const (
+	RoleViewer    Role = "viewer"
+	RoleCommenter Role = "commenter"
+	RoleEditor    Role = "editor"
+	RoleOwner     Role = "owner"
+)
+
+var roleRanks = map[Role]int{
+	RoleViewer:    1,
+	RoleCommenter: 2,
+	RoleEditor:    3,
+	RoleOwner:     4,
+}
+
+// Valid проверяет, что роль известна
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Allows проверяет, что роль не ниже требуемой
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// User представляет учетную запись пользователя
+type User struct {
 	ID           string    `json:"id" gorm:"primaryKey;size:32"`
+	Email        string    `json:"email" gorm:"not null;size:255;uniqueIndex"`
 	Name         string    `json:"name" gorm:"not null;size:255"`
 	PasswordHash string    `json:"-" gorm:"not null;size:255"`
 	CreatedAt    time.Time `json:"created" gorm:"autoCreateTime"`
 	UpdatedAt    time.Time `json:"updated" gorm:"autoUpdateTime"`
+}
 
-	// Связь с колонками
-	Columns []Column `json:"columns" gorm:"foreignKey:BoardID"`
+// BoardMember связывает пользователя с доской, к которой у него есть доступ
+type BoardMember struct {
+	BoardID   string    `json:"board_id" gorm:"primaryKey;size:32"`
+	UserID    string    `json:"user_id" gorm:"primaryKey;size:32;index"`
+	Role      Role      `json:"role" gorm:"not null;size:20;default:editor"`
+	CreatedAt time.Time `json:"created" gorm:"autoCreateTime"`
+
+	// Связи
+	Board Board `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
+	User  User  `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
-// Column представляет колонку на доске (теперь динамические)
+// Column представляет колонку на доске (теперь динамические).
+// Порядок колонок задается строковым рангом Rank (см. пакет lexorank).
+// WIPLimit ограничивает число карточек в колонке (0 — без лимита): жесткий лимит
+// запрещает превышение, мягкий только отмечает его.
 type Column struct {
+	ID        string         `json:"id" gorm:"primaryKey;size:32"`
+	BoardID   string         `json:"board_id" gorm:"not null;size:32;index;uniqueIndex:idx_columns_board_rank,priority:1"`
+	Name      string         `json:"name" gorm:"not null;size:100"`
+	Rank      string         `json:"rank" gorm:"type:varchar(255) COLLATE \"C\";not null;uniqueIndex:idx_columns_board_rank,priority:2,where:deleted_at IS NULL"`
+	Version   int            `json:"version" gorm:"not null;default:1"`
+	CreatedBy string         `json:"created_by" gorm:"size:32"`
+	CreatedAt time.Time      `json:"created" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted,omitempty" gorm:"index"`
+
+	WIPLimit     int  `json:"wip_limit" gorm:"column:wip_limit;not null;default:0"`
+	WIPLimitHard bool `json:"wip_limit_hard" gorm:"column:wip_limit_hard;not null;default:false"`
+
+	// SortBy задает порядок карточек колонки в ответе доски; ручной порядок (ранги) не меняется.
+	// При сортировке по пользовательскому полю SortFieldID — ID этого поля.
+	SortBy      ColumnSort `json:"sort_by" gorm:"not null;size:20;default:''"`
+	SortFieldID string     `json:"sort_field_id,omitempty" gorm:"size:32"`
+
+	// Вычисляемые поля для ответа доски
+	CardCount    int  `json:"card_count" gorm:"-"`
+	OverWIPLimit bool `json:"over_wip_limit" gorm:"-"`
+
+	// Связи
+	Board Board  `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
+	Cards []Card `json:"cards" gorm:"foreignKey:ColumnID"`
+}
+
+// Card представляет карточку задачи. Порядок внутри колонки задается рангом Rank.
+// Архивная карточка (ArchivedAt задан) скрыта с доски и не занимает ранг в колонке.
+type Card struct {
+	ID          string         `json:"id" gorm:"primaryKey;size:32"`
+	BoardID     string         `json:"board_id" gorm:"not null;size:32;index"`
+	Title       string         `json:"title" gorm:"not null;size:500"`
+	Description string         `json:"description" gorm:"type:text"`
+	Assignee    string         `json:"assignee" gorm:"size:255"`
+	Deadline    *time.Time     `json:"deadline" gorm:"type:timestamp"`
+	Priority    Priority       `json:"priority" gorm:"not null;size:10;default:''"`
+	Estimate    *float64       `json:"estimate"`
+	ColumnID    string         `json:"column_id" gorm:"not null;size:32;index;uniqueIndex:idx_cards_column_rank,priority:1"`
+	SwimlaneID  *string        `json:"swimlane_id" gorm:"size:32;index"`
+	Rank        string         `json:"rank" gorm:"type:varchar(255) COLLATE \"C\";not null;uniqueIndex:idx_cards_column_rank,priority:2,where:deleted_at IS NULL AND archived_at IS NULL"`
+	Version     int            `json:"version" gorm:"not null;default:1"`
+	CreatedBy   string         `json:"created_by" gorm:"size:32"`
+	UpdatedBy   string         `json:"updated_by" gorm:"size:32"`
+	CreatedAt   time.Time      `json:"created" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated" gorm:"autoUpdateTime"`
+	ArchivedAt  *time.Time     `json:"archived_at,omitempty" gorm:"index"`
+	DeletedAt   gorm.DeletedAt `json:"deleted,omitempty" gorm:"index"`
+
+	// Значения пользовательских полей доски по ID поля
+	CustomFields CustomFieldValues `json:"custom_fields" gorm:"type:jsonb;not null;default:'{}'"`
+
+	// CommentText — тексты неудаленных комментариев карточки для полнотекстового поиска.
+	// Вместе с заголовком и описанием входит в генерируемый столбец search_vector.
+	CommentText string `json:"-" gorm:"type:text;not null;default:''"`
+
+	// Метки карточки
+	Labels []Label `json:"labels" gorm:"many2many:card_labels"`
+
+	// Вычисляемые поля для ответа доски
+	CommentCount      int64              `json:"comment_count" gorm:"-"`
+	ChecklistProgress *ChecklistProgress `json:"checklist_progress,omitempty" gorm:"-"`
+
+	// WIPLimitExceeded отмечает в ответе, что операция превысила мягкий WIP-лимит колонки
+	WIPLimitExceeded bool `json:"wip_limit_exceeded,omitempty" gorm:"-"`
+
+	// Связи
+	Board    Board     `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
+	Column   Column    `json:"-" gorm:"foreignKey:ColumnID;constraint:OnDelete:CASCADE"`
+	Swimlane *Swimlane `json:"-" gorm:"foreignKey:SwimlaneID;constraint:OnDelete:SET NULL"`
+}
+
+// Priority — приоритет карточки; пустое значение означает «не задан»
+type Priority string
+
+const (
+	PriorityNone   Priority = ""
+	PriorityLow    Priority = "low"
+	PriorityMedium Priority = "medium"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// priorityWeights задает старшинство приоритетов для сортировки
+var priorityWeights = map[Priority]int{
+	PriorityNone:   0,
+	PriorityLow:    1,
+	PriorityMedium: 2,
+	PriorityHigh:   3,
+	PriorityUrgent: 4,
+}
+
+// Valid проверяет, что приоритет известен
+func (p Priority) Valid() bool {
+	_, ok := priorityWeights[p]
+	return ok
+}
+
+// Weight возвращает старшинство приоритета: чем больше, тем срочнее
+func (p Priority) Weight() int {
+	return priorityWeights[p]
+}
+
+// ColumnSort определяет порядок карточек колонки в ответе доски
+type ColumnSort string
+
+const (
+	ColumnSortManual   ColumnSort = ""         // по рангам, как расставили вручную
+	ColumnSortPriority ColumnSort = "priority" // сначала срочные
+	ColumnSortDeadline ColumnSort = "deadline" // сначала ближайший срок, без срока — в конце
+	ColumnSortCreated  ColumnSort = "created"  // сначала новые
+	ColumnSortField    ColumnSort = "field"    // по возрастанию значения пользовательского поля, без значения — в конце
+)
+
+// Valid проверяет, что порядок сортировки известен
+func (s ColumnSort) Valid() bool {
+	return s == ColumnSortManual || s == ColumnSortPriority || s == ColumnSortDeadline ||
+		s == ColumnSortCreated || s == ColumnSortField
+}
+
+// SavedView — именованный фильтр доски. Представлением можно поделиться ссылкой
+// с параметром view=<id>: его видят все участники доски.
+type SavedView struct {
 	ID        string    `json:"id" gorm:"primaryKey;size:32"`
-	BoardID   string    `json:"board_id" gorm:"not null;size:32;index"`
+	BoardID   string    `json:"board_id" gorm:"not null;size:32;uniqueIndex:idx_saved_views_board_name,priority:1"`
+	Name      string    `json:"name" gorm:"not null;size:100;uniqueIndex:idx_saved_views_board_name,priority:2"`
+	Query     string    `json:"query" gorm:"not null;size:1000"`
+	CreatedBy string    `json:"created_by" gorm:"size:32"`
+	CreatedAt time.Time `json:"created" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated" gorm:"autoUpdateTime"`
+
+	// Связи
+	Board Board `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
+}
+
+// CustomField — пользовательское поле карточек, определенное на уровне доски.
+// Options — допустимые варианты для полей с выбором.
+type CustomField struct {
+	ID        string          `json:"id" gorm:"primaryKey;size:32"`
+	BoardID   string          `json:"board_id" gorm:"not null;size:32;uniqueIndex:idx_custom_fields_board_name,priority:1"`
+	Name      string          `json:"name" gorm:"not null;size:100;uniqueIndex:idx_custom_fields_board_name,priority:2"`
+	Type      CustomFieldType `json:"type" gorm:"not null;size:20"`
+	Options   []string        `json:"options" gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time       `json:"created" gorm:"autoCreateTime"`
+	UpdatedAt time.Time       `json:"updated" gorm:"autoUpdateTime"`
+
+	// Связи
+	Board Board `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
+}
+
+// CustomFieldType — тип значения пользовательского поля
+type CustomFieldType string
+
+const (
+	CustomFieldText        CustomFieldType = "text"         // строка
+	CustomFieldNumber      CustomFieldType = "number"       // число
+	CustomFieldDate        CustomFieldType = "date"         // дата в формате YYYY-MM-DD
+	CustomFieldSelect      CustomFieldType = "select"       // один вариант из Options
+	CustomFieldMultiSelect CustomFieldType = "multi_select" // несколько вариантов из Options
+	CustomFieldCheckbox    CustomFieldType = "checkbox"     // флажок
+	CustomFieldURL         CustomFieldType = "url"          // ссылка http или https
+)
+
+// Valid проверяет, что тип поля известен
+func (t CustomFieldType) Valid() bool {
+	switch t {
+	case CustomFieldText, CustomFieldNumber, CustomFieldDate, CustomFieldSelect,
+		CustomFieldMultiSelect, CustomFieldCheckbox, CustomFieldURL:
+		return true
+	}
+	return false
+}
+
+// HasOptions сообщает, выбирается ли значение поля из списка вариантов
+func (t CustomFieldType) HasOptions() bool {
+	return t == CustomFieldSelect || t == CustomFieldMultiSelect
+}
+
+// CustomFieldValues — значения пользовательских полей карточки по ID поля.
+// Хранится в jsonb; отсутствие ключа означает, что значение не задано.
+type CustomFieldValues map[string]interface{}
+
+// Value сериализует значения в JSON для записи в БД
+func (v CustomFieldValues) Value() (driver.Value, error) {
+	if v == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan читает значения из jsonb
+func (v *CustomFieldValues) Scan(src interface{}) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*v = CustomFieldValues{}
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return errors.New("неподдерживаемый тип значений пользовательских полей")
+	}
+
+	values := CustomFieldValues{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	*v = values
+	return nil
+}
+
+// Swimlane — горизонтальная дорожка доски (например, команда или класс обслуживания).
+// Порядок дорожек задается рангом Rank.
+type Swimlane struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:32"`
+	BoardID   string    `json:"board_id" gorm:"not null;size:32;uniqueIndex:idx_swimlanes_board_rank,priority:1"`
 	Name      string    `json:"name" gorm:"not null;size:100"`
-	OrderNum  int       `json:"order" gorm:"not null"`
+	Rank      string    `json:"rank" gorm:"type:varchar(255) COLLATE \"C\";not null;uniqueIndex:idx_swimlanes_board_rank,priority:2"`
+	Collapsed bool      `json:"collapsed" gorm:"not null;default:false"`
 	CreatedAt time.Time `json:"created" gorm:"autoCreateTime"`
 	UpdatedAt time.Time `json:"updated" gorm:"autoUpdateTime"`
 
 	// Связи
-	Board Board  `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
-	Cards []Card `json:"cards" gorm:"foreignKey:ColumnID"`
+	Board Board `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
 }
 
-// Card представляет карточку задачи
-type Card struct {
+// Lane — дорожка в ответе доски. Cells хранит ID карточек каждой колонки дорожки
+// в порядке колонки; сами карточки находятся в Columns.
+type Lane struct {
+	// Key — ID дорожки, ответственный или ID метки в зависимости от группировки;
+	// пустой ключ у дорожки карточек, не попавших ни в одну группу
+	Key       string              `json:"key"`
+	Name      string              `json:"name"`
+	Color     string              `json:"color,omitempty"`
+	Collapsed bool                `json:"collapsed"`
+	Cells     map[string][]string `json:"cells"`
+}
+
+// Label — метка из каталога доски (например, bug, feature, urgent)
+type Label struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:32"`
+	BoardID   string    `json:"board_id" gorm:"not null;size:32;uniqueIndex:idx_labels_board_name,priority:1"`
+	Name      string    `json:"name" gorm:"not null;size:100;uniqueIndex:idx_labels_board_name,priority:2"`
+	Color     string    `json:"color" gorm:"not null;size:7"`
+	CreatedAt time.Time `json:"created" gorm:"autoCreateTime"`
+
+	// Связи
+	Board Board `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
+}
+
+// CardLabel связывает карточку с меткой
+type CardLabel struct {
+	CardID  string `gorm:"primaryKey;size:32"`
+	LabelID string `gorm:"primaryKey;size:32;index"`
+
+	// Связи
+	Card  Card  `gorm:"foreignKey:CardID;constraint:OnDelete:CASCADE"`
+	Label Label `gorm:"foreignKey:LabelID;constraint:OnDelete:CASCADE"`
+}
+
+// Checklist — список шагов внутри карточки
+type Checklist struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:32"`
+	BoardID   string    `json:"board_id" gorm:"not null;size:32;index"`
+	CardID    string    `json:"card_id" gorm:"not null;size:32;uniqueIndex:idx_checklists_card_rank,priority:1"`
+	Title     string    `json:"title" gorm:"not null;size:255"`
+	Rank      string    `json:"rank" gorm:"type:varchar(255) COLLATE \"C\";not null;uniqueIndex:idx_checklists_card_rank,priority:2"`
+	CreatedAt time.Time `json:"created" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated" gorm:"autoUpdateTime"`
+
+	// Связи
+	Card  Card            `json:"-" gorm:"foreignKey:CardID;constraint:OnDelete:CASCADE"`
+	Items []ChecklistItem `json:"items" gorm:"foreignKey:ChecklistID"`
+}
+
+// ChecklistItem — пункт чек-листа
+type ChecklistItem struct {
 	ID          string     `json:"id" gorm:"primaryKey;size:32"`
-	BoardID     string     `json:"board_id" gorm:"not null;size:32;index"`
-	Title       string     `json:"title" gorm:"not null;size:500"`
-	Description string     `json:"description" gorm:"type:text"`
+	ChecklistID string     `json:"checklist_id" gorm:"not null;size:32;uniqueIndex:idx_checklist_items_checklist_rank,priority:1"`
+	Text        string     `json:"text" gorm:"not null;size:500"`
+	Done        bool       `json:"done" gorm:"not null;default:false"`
 	Assignee    string     `json:"assignee" gorm:"size:255"`
-	Deadline    *time.Time `json:"deadline" gorm:"type:timestamp"`
-	ColumnID    string     `json:"column_id" gorm:"not null;size:32;index"`
-	OrderNum    int        `json:"order" gorm:"not null;default:1"`
+	DueDate     *time.Time `json:"due_date" gorm:"type:timestamp"`
+	Rank        string     `json:"rank" gorm:"type:varchar(255) COLLATE \"C\";not null;uniqueIndex:idx_checklist_items_checklist_rank,priority:2"`
 	CreatedAt   time.Time  `json:"created" gorm:"autoCreateTime"`
 	UpdatedAt   time.Time  `json:"updated" gorm:"autoUpdateTime"`
 
 	// Связи
-	Board  Board  `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
-	Column Column `json:"-" gorm:"foreignKey:ColumnID;constraint:OnDelete:CASCADE"`
+	Checklist Checklist `json:"-" gorm:"foreignKey:ChecklistID;constraint:OnDelete:CASCADE"`
+}
+
+// ChecklistProgress — сводка выполнения всех чек-листов карточки
+type ChecklistProgress struct {
+	Done  int64 `json:"done"`
+	Total int64 `json:"total"`
+}
+
+// Attachment — файл, прикрепленный к карточке. Содержимое хранится
+// во внешнем хранилище (см. пакет storage) под ключом StorageKey.
+type Attachment struct {
+	ID          string    `json:"id" gorm:"primaryKey;size:32"`
+	BoardID     string    `json:"board_id" gorm:"not null;size:32;index"`
+	CardID      string    `json:"card_id" gorm:"not null;size:32;index"`
+	FileName    string    `json:"file_name" gorm:"not null;size:255"`
+	ContentType string    `json:"content_type" gorm:"not null;size:255"`
+	Size        int64     `json:"size" gorm:"not null"`
+	StorageKey  string    `json:"-" gorm:"not null;size:100"`
+	UploadedBy  string    `json:"uploaded_by" gorm:"size:32"`
+	CreatedAt   time.Time `json:"created" gorm:"autoCreateTime"`
+
+	// Связи
+	Board Board `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
+	Card  Card  `json:"-" gorm:"foreignKey:CardID;constraint:OnDelete:CASCADE"`
+}
+
+// Comment — комментарий к карточке. Ответы (ParentID) допускаются только
+// на комментарии верхнего уровня.
+type Comment struct {
+	ID        string         `json:"id" gorm:"primaryKey;size:32"`
+	BoardID   string         `json:"board_id" gorm:"not null;size:32;index"`
+	CardID    string         `json:"card_id" gorm:"not null;size:32;index"`
+	ParentID  *string        `json:"parent_id" gorm:"size:32;index"`
+	AuthorID  string         `json:"author_id" gorm:"size:32"`
+	Body      string         `json:"body" gorm:"type:text;not null"`
+	EditedAt  *time.Time     `json:"edited,omitempty"`
+	CreatedAt time.Time      `json:"created" gorm:"autoCreateTime"`
+	UpdatedAt time.Time      `json:"updated" gorm:"autoUpdateTime"`
+	DeletedAt gorm.DeletedAt `json:"deleted,omitempty" gorm:"index"`
+
+	// Ответы на комментарий
+	Replies []Comment `json:"replies,omitempty" gorm:"foreignKey:ParentID"`
+
+	// Связи
+	Card Card `json:"-" gorm:"foreignKey:CardID;constraint:OnDelete:CASCADE"`
 }
 
+// CommentRevision хранит прежний текст комментария при каждом редактировании
+type CommentRevision struct {
+	ID        string    `json:"id" gorm:"primaryKey;size:32"`
+	CommentID string    `json:"comment_id" gorm:"not null;size:32;index"`
+	Body      string    `json:"body" gorm:"type:text;not null"`
+	EditedBy  string    `json:"edited_by" gorm:"size:32"`
+	CreatedAt time.Time `json:"created" gorm:"autoCreateTime"`
+
+	// Связи
+	Comment Comment `json:"-" gorm:"foreignKey:CommentID;constraint:OnDelete:CASCADE"`
+}
+
+// Activity — запись журнала изменений доски
+type Activity struct {
+	ID        string       `json:"id" gorm:"primaryKey;size:32"`
+	BoardID   string       `json:"board_id" gorm:"not null;size:32;index:idx_activities_board_created,priority:1"`
+	ActorID   string       `json:"actor_id" gorm:"size:32;index"`
+	Action    string       `json:"action" gorm:"not null;size:50"`
+	CardID    string       `json:"card_id,omitempty" gorm:"size:32;index"`
+	ColumnID  string       `json:"column_id,omitempty" gorm:"size:32"`
+	Changes   FieldChanges `json:"changes" gorm:"type:jsonb;serializer:json"`
+	CreatedAt time.Time    `json:"created" gorm:"autoCreateTime;index:idx_activities_board_created,priority:2"`
+
+	// Связи (карточки и колонки не связаны внешним ключом, чтобы история пережила удаление)
+	Board Board `json:"-" gorm:"foreignKey:BoardID;constraint:OnDelete:CASCADE"`
+}
+
+// FieldChange хранит значение поля до и после изменения
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// FieldChanges — изменения полей по их именам
+type FieldChanges map[string]FieldChange
+
 // TableName указывает имя таблицы для модели Card
 func (Card) TableName() string {
 	return "cards"
@@ -63,34 +495,139 @@ func (Column) TableName() string {
 	return "columns"
 }
 
+// TableName указывает имя таблицы для модели Swimlane
+func (Swimlane) TableName() string {
+	return "swimlanes"
+}
+
+// TableName указывает имя таблицы для модели Label
+func (Label) TableName() string {
+	return "labels"
+}
+
+// TableName указывает имя таблицы для модели CardLabel
+func (CardLabel) TableName() string {
+	return "card_labels"
+}
+
+// TableName указывает имя таблицы для модели Checklist
+func (Checklist) TableName() string {
+	return "checklists"
+}
+
+// TableName указывает имя таблицы для модели ChecklistItem
+func (ChecklistItem) TableName() string {
+	return "checklist_items"
+}
+
+// TableName указывает имя таблицы для модели Attachment
+func (Attachment) TableName() string {
+	return "attachments"
+}
+
+// TableName указывает имя таблицы для модели Comment
+func (Comment) TableName() string {
+	return "comments"
+}
+
+// TableName указывает имя таблицы для модели CommentRevision
+func (CommentRevision) TableName() string {
+	return "comment_revisions"
+}
+
+// TableName указывает имя таблицы для модели Activity
+func (Activity) TableName() string {
+	return "activities"
+}
+
+// TableName указывает имя таблицы для модели User
+func (User) TableName() string {
+	return "users"
+}
+
+// TableName указывает имя таблицы для модели BoardMember
+func (BoardMember) TableName() string {
+	return "board_members"
+}
+
 // Запросы для API
 type CreateBoardRequest struct {
 	Name     string `json:"name" validate:"required"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"omitempty,min=6"`
 }
 
 type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// Запросы для учетных записей
+type RegisterRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Name     string `json:"name" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+type UserLoginRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type AddMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  Role   `json:"role"`
+}
+
+type UpdateMemberRequest struct {
+	Role Role `json:"role" validate:"required"`
+}
+
+type GuestAccessRequest struct {
+	Enabled  bool   `json:"enabled"`
+	Password string `json:"password"`
+	Role     Role   `json:"role"`
+}
+
 type CreateCardRequest struct {
 	Title       string     `json:"title" validate:"required"`
 	Description string     `json:"description"`
 	Assignee    string     `json:"assignee"`
 	Deadline    *time.Time `json:"deadline"`
 	ColumnID    string     `json:"column_id" validate:"required"`
+	Priority    Priority   `json:"priority"`
+	Estimate    *float64   `json:"estimate"`
+	SwimlaneID  string     `json:"swimlane_id"`
+	LabelIDs    []string   `json:"label_ids"`
+	// CustomFields — значения пользовательских полей по ID поля
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
+// Поле Version во всех запросах изменения необязательно: если оно задано
+// (или передан заголовок If-Match), изменение применяется только к этой версии.
 type UpdateCardRequest struct {
 	Title       string     `json:"title"`
 	Description string     `json:"description"`
 	Assignee    string     `json:"assignee"`
 	Deadline    *time.Time `json:"deadline"`
+	// Priority и Estimate меняются, только если переданы; clear_estimate очищает оценку
+	Priority      *Priority `json:"priority"`
+	Estimate      *float64  `json:"estimate"`
+	ClearEstimate bool      `json:"clear_estimate"`
+	// LabelIDs заменяет метки карточки; nil оставляет их без изменений
+	LabelIDs *[]string `json:"label_ids"`
+	// CustomFields меняет только переданные поля; null очищает значение
+	CustomFields map[string]interface{} `json:"custom_fields"`
+	Version      *int                   `json:"version"`
 }
 
+// MoveCardRequest перемещает карточку в колонку ColumnID на позицию Order
+// (начиная с 1). Order = 0 означает перемещение в конец колонки.
+// Если задан SwimlaneID, карточка переходит в эту дорожку ("" — убрать из дорожки),
+// а Order отсчитывается среди карточек дорожки в колонке.
 type MoveCardRequest struct {
-	ColumnID string `json:"column_id" validate:"required"`
-	Order    int    `json:"order"`
+	ColumnID   string  `json:"column_id" validate:"required"`
+	SwimlaneID *string `json:"swimlane_id"`
+	Order      int     `json:"order"`
+	Version    *int    `json:"version"`
 }
 
 // Запросы для управления колонками
@@ -99,11 +636,175 @@ type CreateColumnRequest struct {
 }
 
 type UpdateColumnRequest struct {
-	Name string `json:"name" validate:"required"`
+	Name    string `json:"name" validate:"required"`
+	Version *int   `json:"version"`
 }
 
+// MoveColumnRequest перемещает колонку на позицию Order (начиная с 1)
 type MoveColumnRequest struct {
-	Order int `json:"order" validate:"required"`
+	Order   int  `json:"order" validate:"required"`
+	Version *int `json:"version"`
+}
+
+type SwimlaneRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+// UpdateSwimlaneRequest изменяет только переданные поля дорожки
+type UpdateSwimlaneRequest struct {
+	Name      *string `json:"name"`
+	Collapsed *bool   `json:"collapsed"`
+}
+
+// MoveSwimlaneRequest перемещает дорожку на позицию Order (начиная с 1, 0 — в конец)
+type MoveSwimlaneRequest struct {
+	Order int `json:"order"`
+}
+
+// SwimlaneGroupingRequest задает способ группировки карточек доски по дорожкам
+type SwimlaneGroupingRequest struct {
+	Grouping SwimlaneGrouping `json:"grouping"`
+}
+
+// ColumnSortRequest задает порядок карточек колонки в ответе доски.
+// SortFieldID обязателен при сортировке по пользовательскому полю.
+type ColumnSortRequest struct {
+	SortBy      ColumnSort `json:"sort_by"`
+	SortFieldID string     `json:"sort_field_id"`
+	Version     *int       `json:"version"`
+}
+
+// BoardQuery — параметры запроса доски. Query — фильтр на языке фильтров доски
+// (например, "assignee:ivan label:bug due:<7d -column:done"), View — ID сохраненного
+// представления, фильтр которого применяется вместе с Query. Filters — фильтры
+// по пользовательским полям в виде "<id поля>:<значение>"; пустое значение отбирает
+// карточки без значения поля.
+type BoardQuery struct {
+	Query   string   `query:"q"`
+	View    string   `query:"view"`
+	Filters []string `query:"cf"`
+}
+
+// SavedViewRequest сохраняет именованный фильтр доски
+type SavedViewRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Query string `json:"query"`
+}
+
+// CustomFieldRequest создает пользовательское поле; Options нужны для select и multi_select
+type CustomFieldRequest struct {
+	Name    string          `json:"name" validate:"required"`
+	Type    CustomFieldType `json:"type" validate:"required"`
+	Options []string        `json:"options"`
+}
+
+// UpdateCustomFieldRequest изменяет только переданные поля; тип поля не меняется.
+// Значения карточек с убранными вариантами очищаются.
+type UpdateCustomFieldRequest struct {
+	Name    *string   `json:"name"`
+	Options *[]string `json:"options"`
+}
+
+// WIPLimitRequest задает WIP-лимит колонки; Limit = 0 снимает лимит
+type WIPLimitRequest struct {
+	Limit   int  `json:"limit"`
+	Hard    bool `json:"hard"`
+	Version *int `json:"version"`
+}
+
+// ColumnDeleteMode определяет, что происходит с карточками удаляемой колонки
+type ColumnDeleteMode string
+
+const (
+	ColumnDeleteMove    ColumnDeleteMode = "move"    // перенос в другую колонку
+	ColumnDeleteArchive ColumnDeleteMode = "archive" // отправка в архив
+	ColumnDeleteCards   ColumnDeleteMode = "delete"  // удаление в корзину вместе с колонкой
+)
+
+// Valid проверяет, что режим удаления колонки известен
+func (m ColumnDeleteMode) Valid() bool {
+	return m == ColumnDeleteMove || m == ColumnDeleteArchive || m == ColumnDeleteCards
+}
+
+// DeleteColumnQuery задает режим удаления колонки. Для колонки без карточек режим не нужен;
+// для режима move обязателен TargetColumnID.
+type DeleteColumnQuery struct {
+	Mode           ColumnDeleteMode `query:"mode"`
+	TargetColumnID string           `query:"target_column_id"`
+}
+
+// TrashRetentionRequest задает срок хранения корзины доски в днях
+type TrashRetentionRequest struct {
+	Days int `json:"days" validate:"required"`
+}
+
+type LabelRequest struct {
+	Name  string `json:"name" validate:"required"`
+	Color string `json:"color" validate:"required"`
+}
+
+type ChecklistRequest struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type CreateChecklistItemRequest struct {
+	Text     string     `json:"text" validate:"required"`
+	Assignee string     `json:"assignee"`
+	DueDate  *time.Time `json:"due_date"`
+}
+
+// UpdateChecklistItemRequest изменяет только переданные поля пункта.
+// Чтобы очистить срок, передайте clear_due_date.
+type UpdateChecklistItemRequest struct {
+	Text         *string    `json:"text"`
+	Done         *bool      `json:"done"`
+	Assignee     *string    `json:"assignee"`
+	DueDate      *time.Time `json:"due_date"`
+	ClearDueDate bool       `json:"clear_due_date"`
+}
+
+// MoveChecklistItemRequest перемещает пункт на позицию Order (начиная с 1, 0 — в конец)
+// в чек-лист ChecklistID той же карточки (пустой — в текущий)
+type MoveChecklistItemRequest struct {
+	ChecklistID string `json:"checklist_id"`
+	Order       int    `json:"order"`
+}
+
+// CreateCommentRequest создает комментарий или ответ на комментарий ParentID
+type CreateCommentRequest struct {
+	Body     string `json:"body" validate:"required"`
+	ParentID string `json:"parent_id"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body" validate:"required"`
+}
+
+// ActivityQuery задает фильтры и курсор для журнала изменений.
+// Время передается в формате RFC 3339, Cursor — ID последней полученной записи.
+type ActivityQuery struct {
+	CardID  string `query:"card_id"`
+	ActorID string `query:"actor_id"`
+	From    string `query:"from"`
+	To      string `query:"to"`
+	Cursor  string `query:"cursor"`
+	Limit   int    `query:"limit"`
+}
+
+// ArchivedCardsQuery задает поиск и курсор для списка архивных карточек.
+// Cursor — ID последней полученной карточки.
+type ArchivedCardsQuery struct {
+	Search   string `query:"q"`
+	ColumnID string `query:"column_id"`
+	Cursor   string `query:"cursor"`
+	Limit    int    `query:"limit"`
+}
+
+// UnarchiveCardsRequest возвращает карточки CardIDs из архива. Если ColumnID задан,
+// карточки встают в конец этой колонки, иначе — на прежние места в своих колонках.
+type UnarchiveCardsRequest struct {
+	CardIDs  []string `json:"card_ids"`
+	ColumnID string   `json:"column_id"`
 }
 
 // Ответы API
@@ -112,28 +813,282 @@ type LoginResponse struct {
 	BoardID string `json:"board_id"`
 }
 
+type BoardSummary struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserResponse struct {
+	User    User           `json:"user"`
+	Boards  []BoardSummary `json:"boards"`
+	BoardID string         `json:"board_id,omitempty"`
+	Role    Role           `json:"role,omitempty"`
+}
+
+type ActivityPage struct {
+	Items      []Activity `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// TrashResponse — содержимое корзины доски. Карточки, удаленные вместе с колонкой,
+// находятся в Cards этой колонки, а не в общем списке.
+type TrashResponse struct {
+	RetentionDays int      `json:"retention_days"`
+	Columns       []Column `json:"columns"`
+	Cards         []Card   `json:"cards"`
+}
+
+type CardPage struct {
+	Items      []Card `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CardSearchQuery задает полнотекстовый поиск карточек доски. Query понимает синтаксис
+// websearch_to_tsquery: "фразы в кавычках", OR и -исключение. DueFrom и DueTo — границы
+// дедлайна в RFC3339.
+type CardSearchQuery struct {
+	Query    string         `query:"q"`
+	ColumnID string         `query:"column_id"`
+	Assignee string         `query:"assignee"`
+	LabelID  string         `query:"label_id"`
+	DueFrom  string         `query:"due_from"`
+	DueTo    string         `query:"due_to"`
+	Archived ArchivedFilter `query:"archived"`
+	Offset   int            `query:"offset"`
+	Limit    int            `query:"limit"`
+}
+
+// ArchivedFilter определяет, ищутся ли архивные карточки
+type ArchivedFilter string
+
+const (
+	ArchivedExclude ArchivedFilter = ""     // только карточки на доске
+	ArchivedOnly    ArchivedFilter = "only" // только архивные
+	ArchivedAll     ArchivedFilter = "all"  // все
+)
+
+// Valid проверяет, что фильтр архива известен
+func (f ArchivedFilter) Valid() bool {
+	return f == ArchivedExclude || f == ArchivedOnly || f == ArchivedAll
+}
+
+// CardSearchResult — найденная карточка с релевантностью и фрагментами текста,
+// в которых совпадения выделены тегом <mark>. Остальной текст фрагментов экранирован.
+type CardSearchResult struct {
+	Card               Card    `json:"card"`
+	Score              float64 `json:"score"`
+	TitleSnippet       string  `json:"title_snippet"`
+	DescriptionSnippet string  `json:"description_snippet,omitempty"`
+	CommentSnippet     string  `json:"comment_snippet,omitempty"`
+}
+
+type CardSearchPage struct {
+	Items      []CardSearchResult `json:"items"`
+	NextOffset int                `json:"next_offset,omitempty"`
+}
+
+// BoardExportVersion — текущая версия схемы документа экспорта доски
+const BoardExportVersion = 1
+
+// BoardExport — документ экспорта доски. ID в документе связывают сущности между собой
+// и при импорте заменяются новыми. Порядок колонок, дорожек, карточек внутри колонки
+// и чек-листов задается порядком элементов в массивах.
+type BoardExport struct {
+	Version      int                   `json:"version"`
+	ExportedAt   time.Time             `json:"exported_at"`
+	Board        ExportedBoard         `json:"board"`
+	Columns      []ExportedColumn      `json:"columns"`
+	Swimlanes    []ExportedSwimlane    `json:"swimlanes"`
+	Labels       []ExportedLabel       `json:"labels"`
+	CustomFields []ExportedCustomField `json:"custom_fields"`
+	Views        []ExportedView        `json:"views"`
+
+	// При экспорте карточки дописываются в поток пачками после остальных полей
+	Cards []ExportedCard `json:"cards"`
+}
+
+type ExportedBoard struct {
+	Name               string           `json:"name"`
+	GuestRole          Role             `json:"guest_role"`
+	TrashRetentionDays int              `json:"trash_retention_days"`
+	SwimlaneGrouping   SwimlaneGrouping `json:"swimlane_grouping"`
+}
+
+type ExportedColumn struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	WIPLimit     int        `json:"wip_limit"`
+	WIPLimitHard bool       `json:"wip_limit_hard"`
+	SortBy       ColumnSort `json:"sort_by"`
+	SortFieldID  string     `json:"sort_field_id,omitempty"`
+}
+
+type ExportedSwimlane struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Collapsed bool   `json:"collapsed"`
+}
+
+type ExportedLabel struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type ExportedCustomField struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Type      CustomFieldType `json:"type"`
+	Options   []string        `json:"options,omitempty"`
+	CreatedAt time.Time       `json:"created"`
+}
+
+type ExportedView struct {
+	Name      string `json:"name"`
+	Query     string `json:"query"`
+	CreatedBy string `json:"created_by,omitempty"`
+}
+
+// ExportedCard — карточка с чек-листами, комментариями и описанием вложений.
+// Содержимое вложений в документ не входит.
+type ExportedCard struct {
+	ID           string               `json:"id"`
+	ColumnID     string               `json:"column_id"`
+	SwimlaneID   *string              `json:"swimlane_id,omitempty"`
+	Title        string               `json:"title"`
+	Description  string               `json:"description"`
+	Assignee     string               `json:"assignee"`
+	Deadline     *time.Time           `json:"deadline,omitempty"`
+	Priority     Priority             `json:"priority"`
+	Estimate     *float64             `json:"estimate,omitempty"`
+	LabelIDs     []string             `json:"label_ids"`
+	CustomFields CustomFieldValues    `json:"custom_fields"`
+	CreatedAt    time.Time            `json:"created"`
+	UpdatedAt    time.Time            `json:"updated"`
+	ArchivedAt   *time.Time           `json:"archived_at,omitempty"`
+	Checklists   []ExportedChecklist  `json:"checklists"`
+	Comments     []ExportedComment    `json:"comments"`
+	Attachments  []ExportedAttachment `json:"attachments"`
+}
+
+type ExportedChecklist struct {
+	Title string                  `json:"title"`
+	Items []ExportedChecklistItem `json:"items"`
+}
+
+type ExportedChecklistItem struct {
+	Text     string     `json:"text"`
+	Done     bool       `json:"done"`
+	Assignee string     `json:"assignee"`
+	DueDate  *time.Time `json:"due_date,omitempty"`
+}
+
+// ExportedComment — комментарий карточки; ParentID ссылается на ID комментария в документе.
+// ID пользователей в документе справочные: при импорте комментарии не привязываются
+// к учетным записям сервера, а AuthorName ставится в начало текста. Deleted — удаленный
+// комментарий без текста, оставленный ради ответов на него.
+type ExportedComment struct {
+	ID         string                    `json:"id"`
+	ParentID   *string                   `json:"parent_id,omitempty"`
+	AuthorID   string                    `json:"author_id"`
+	AuthorName string                    `json:"author_name,omitempty"`
+	Body       string                    `json:"body"`
+	Deleted    bool                      `json:"deleted,omitempty"`
+	CreatedAt  time.Time                 `json:"created"`
+	EditedAt   *time.Time                `json:"edited,omitempty"`
+	Revisions  []ExportedCommentRevision `json:"revisions,omitempty"`
+}
+
+type ExportedCommentRevision struct {
+	Body      string    `json:"body"`
+	EditedBy  string    `json:"edited_by"`
+	CreatedAt time.Time `json:"created"`
+}
+
+type ExportedAttachment struct {
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploadedBy  string    `json:"uploaded_by"`
+	CreatedAt   time.Time `json:"created"`
+}
+
+// TrelloImportQuery — параметры импорта доски из экспорта Trello. С DryRun доска
+// не создается, а возвращается только отчет о том, что было бы создано.
+type TrelloImportQuery struct {
+	DryRun bool `query:"dry_run"`
+}
+
+// TrelloImportReport — что создается (или было бы создано) при импорте доски из Trello
+type TrelloImportReport struct {
+	Board          string   `json:"board"`
+	Columns        int      `json:"columns"`
+	Cards          int      `json:"cards"`
+	ArchivedCards  int      `json:"archived_cards"`
+	Labels         int      `json:"labels"`
+	Checklists     int      `json:"checklists"`
+	ChecklistItems int      `json:"checklist_items"`
+	Comments       int      `json:"comments"`
+	Assignees      []string `json:"assignees"`
+	Warnings       []string `json:"warnings"`
+}
+
+type TrelloImportResult struct {
+	DryRun bool               `json:"dry_run"`
+	Report TrelloImportReport `json:"report"`
+	Board  *Board             `json:"board,omitempty"`
+}
+
+// CSVImportQuery задает сопоставление полей карточки столбцам CSV: map=<поле>:<заголовок>.
+// Поля без сопоставления ищутся по заголовку, совпадающему с названием поля.
+type CSVImportQuery struct {
+	Mapping []string `query:"map"`
+}
+
+// CSVRowError — ошибка строки CSV; Row — номер строки в файле, заголовок — строка 1
+type CSVRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// CSVImportResult — итог импорта карточек из CSV. Строки с ошибками пропускаются,
+// остальные применяются.
+type CSVImportResult struct {
+	Created        int           `json:"created"`
+	Updated        int           `json:"updated"`
+	ColumnsCreated []string      `json:"columns_created"`
+	Errors         []CSVRowError `json:"errors"`
+	Warnings       []CSVRowError `json:"warnings"`
+}
+
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// ConflictResponse возвращается с кодом 409, когда объект изменили с момента его получения
+type ConflictResponse struct {
+	Error   string      `json:"error"`
+	Current interface{} `json:"current"`
+}
+
 // Предустановленные колонки
 var DefaultColumns = []Column{
 	{
-		ID:       "todo",
-		Name:     "Актуальные задачи",
-		OrderNum: 1,
-		Cards:    []Card{},
+		ID:    "todo",
+		Name:  "Актуальные задачи",
+		Rank:  "9",
+		Cards: []Card{},
 	},
 	{
-		ID:       "in-progress",
-		Name:     "В работе",
-		OrderNum: 2,
-		Cards:    []Card{},
+		ID:    "in-progress",
+		Name:  "В работе",
+		Rank:  "i",
+		Cards: []Card{},
 	},
 	{
-		ID:       "done",
-		Name:     "Выполн��но",
-		OrderNum: 3,
-		Cards:    []Card{},
+		ID:    "done",
+		Name:  "Выполнено",
+		Rank:  "r",
+		Cards: []Card{},
 	},
 }
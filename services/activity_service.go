@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"task-board/database"
+	"task-board/events"
+	"task-board/models"
+)
+
+const (
+	defaultActivityLimit = 50
+	maxActivityLimit     = 200
+)
+
+type ActivityService struct {
+	db *gorm.DB
+}
+
+func NewActivityService() *ActivityService {
+	return &ActivityService{
+		db: database.DB,
+	}
+}
+
+// List возвращает записи журнала доски от новых к старым.
+// Следующая страница запрашивается с Cursor, равным NextCursor предыдущей.
+func (s *ActivityService) List(boardID string, q models.ActivityQuery) (*models.ActivityPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+	if limit > maxActivityLimit {
+		limit = maxActivityLimit
+	}
+
+	query := s.db.Where("board_id = ?", boardID)
+
+	if q.CardID != "" {
+		query = query.Where("card_id = ?", q.CardID)
+	}
+	if q.ActorID != "" {
+		query = query.Where("actor_id = ?", q.ActorID)
+	}
+	if q.From != "" {
+		from, err := time.Parse(time.RFC3339, q.From)
+		if err != nil {
+			return nil, errors.New("неверный формат времени from")
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if q.To != "" {
+		to, err := time.Parse(time.RFC3339, q.To)
+		if err != nil {
+			return nil, errors.New("неверный формат времени to")
+		}
+		query = query.Where("created_at < ?", to)
+	}
+
+	// Курсор — ID последней записи предыдущей страницы
+	if q.Cursor != "" {
+		query = query.Where("(created_at, id) < (SELECT created_at, id FROM activities WHERE id = ? AND board_id = ?)",
+			q.Cursor, boardID)
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница
+	items := []models.Activity{}
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&items).Error; err != nil {
+		return nil, errors.New("ошибка получения журнала изменений")
+	}
+
+	page := &models.ActivityPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = page.Items[limit-1].ID
+	}
+
+	return page, nil
+}
+
+// recordActivity записывает действие в журнал доски в рамках транзакции изменения
+func recordActivity(tx *gorm.DB, actor Actor, action events.Type, boardID, cardID, columnID string, changes models.FieldChanges) error {
+	activity := &models.Activity{
+		ID:       generateID(),
+		BoardID:  boardID,
+		ActorID:  actor.UserID,
+		Action:   string(action),
+		CardID:   cardID,
+		ColumnID: columnID,
+		Changes:  changes,
+	}
+
+	if err := tx.Create(activity).Error; err != nil {
+		return errors.New("ошибка записи в журнал изменений")
+	}
+	return nil
+}
+
+// snapshot — значения отслеживаемых полей объекта
+type snapshot map[string]interface{}
+
+// cardSnapshot возвращает отслеживаемые поля карточки
+func cardSnapshot(card *models.Card) snapshot {
+	var deadline interface{}
+	if card.Deadline != nil {
+		deadline = card.Deadline.UTC().Format(time.RFC3339)
+	}
+
+	var swimlaneID interface{}
+	if card.SwimlaneID != nil {
+		swimlaneID = *card.SwimlaneID
+	}
+	var estimate interface{}
+	if card.Estimate != nil {
+		estimate = *card.Estimate
+	}
+
+	values := snapshot{
+		"swimlane_id": swimlaneID,
+		"title":       card.Title,
+		"description": card.Description,
+		"assignee":    card.Assignee,
+		"deadline":    deadline,
+		"priority":    string(card.Priority),
+		"estimate":    estimate,
+		"column_id":   card.ColumnID,
+		"rank":        card.Rank,
+		"labels":      labelIDs(card.Labels),
+	}
+	// Каждое пользовательское поле отслеживается отдельно
+	for id, value := range card.CustomFields {
+		values["custom_fields."+id] = value
+	}
+	return values
+}
+
+// columnSnapshot возвращает отслеживаемые поля колонки
+func columnSnapshot(column *models.Column) snapshot {
+	values := snapshot{
+		"name": column.Name,
+		"rank": column.Rank,
+	}
+	// Лимит и сортировка попадают в журнал только если заданы,
+	// чтобы не засорять записи о создании колонок
+	if column.WIPLimit > 0 {
+		values["wip_limit"] = column.WIPLimit
+		values["wip_limit_hard"] = column.WIPLimitHard
+	}
+	if column.SortBy != models.ColumnSortManual {
+		values["sort_by"] = string(column.SortBy)
+	}
+	if column.SortFieldID != "" {
+		values["sort_field_id"] = column.SortFieldID
+	}
+	return values
+}
+
+// diffSnapshots возвращает поля, значения которых отличаются.
+// Пустой before означает создание объекта, пустой after — удаление.
+func diffSnapshots(before, after snapshot) models.FieldChanges {
+	changes := models.FieldChanges{}
+
+	for field, value := range after {
+		if old := before[field]; !reflect.DeepEqual(old, value) && !(isEmpty(old) && isEmpty(value)) {
+			changes[field] = models.FieldChange{Before: old, After: value}
+		}
+	}
+	for field, old := range before {
+		if _, ok := after[field]; !ok && !isEmpty(old) {
+			changes[field] = models.FieldChange{Before: old, After: nil}
+		}
+	}
+
+	return changes
+}
+
+func isEmpty(value interface{}) bool {
+	return value == nil || value == ""
+}
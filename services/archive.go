@@ -0,0 +1,281 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"task-board/events"
+	"task-board/lexorank"
+	"task-board/models"
+)
+
+// ErrArchivedColumnGone возвращается, если архивную карточку некуда вернуть без явной колонки
+var ErrArchivedColumnGone = errors.New("колонка карточки в корзине, укажите column_id для возврата из архива")
+
+const (
+	defaultArchiveLimit = 50
+	maxArchiveLimit     = 200
+)
+
+// ListArchivedCards возвращает архивные карточки доски от недавно архивированных к давним.
+// Search ищет подстроку в заголовке и описании без учета регистра.
+func (s *BoardService) ListArchivedCards(boardID string, q models.ArchivedCardsQuery) (*models.CardPage, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultArchiveLimit
+	}
+	if limit > maxArchiveLimit {
+		limit = maxArchiveLimit
+	}
+
+	query := s.db.Preload("Labels", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name ASC")
+	}).Where("board_id = ? AND archived_at IS NOT NULL", boardID)
+
+	if q.ColumnID != "" {
+		query = query.Where("column_id = ?", q.ColumnID)
+	}
+	if search := strings.TrimSpace(q.Search); search != "" {
+		pattern := "%" + escapeLike(search) + "%"
+		query = query.Where("title ILIKE ? OR description ILIKE ?", pattern, pattern)
+	}
+
+	// Курсор — ID последней карточки предыдущей страницы
+	if q.Cursor != "" {
+		query = query.Where("(archived_at, id) < (SELECT archived_at, id FROM cards WHERE id = ? AND board_id = ?)",
+			q.Cursor, boardID)
+	}
+
+	// Запрашиваем на одну карточку больше, чтобы понять, есть ли следующая страница
+	cards := []models.Card{}
+	if err := query.Order("archived_at DESC, id DESC").Limit(limit + 1).Find(&cards).Error; err != nil {
+		return nil, errors.New("ошибка получения архива")
+	}
+
+	page := &models.CardPage{Items: cards}
+	if len(cards) > limit {
+		page.Items = cards[:limit]
+		page.NextCursor = page.Items[limit-1].ID
+	}
+
+	return page, nil
+}
+
+// ArchiveCard отправляет карточку в архив: она скрывается с доски, но не удаляется
+func (s *BoardService) ArchiveCard(actor Actor, boardID, cardID string) (*models.Card, error) {
+	var card models.Card
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND board_id = ?", cardID, boardID).First(&card).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("карточка не найдена")
+			}
+			return errors.New("ошибка получения карточки")
+		}
+		if card.ArchivedAt != nil {
+			return errors.New("карточка уже в архиве")
+		}
+
+		if err := archiveCards(tx, actor, boardID, []models.Card{card}); err != nil {
+			return err
+		}
+
+		if err := tx.Preload("Labels").First(&card, "id = ?", cardID).Error; err != nil {
+			return errors.New("ошибка перезагрузки карточки")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CardArchived, actor, boardID, deletedPayload{ID: cardID})
+
+	return &card, nil
+}
+
+// ArchiveColumnCards отправляет в архив все карточки колонки и возвращает их число
+func (s *BoardService) ArchiveColumnCards(actor Actor, boardID, columnID string) (int, error) {
+	var cards []models.Card
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Column{}).Where("id = ? AND board_id = ?", columnID, boardID).
+			Count(&count).Error; err != nil {
+			return errors.New("ошибка получения колонки")
+		}
+		if count == 0 {
+			return errors.New("колонка не найдена")
+		}
+
+		if err := rankedCards(tx, columnID).Order("rank ASC").Find(&cards).Error; err != nil {
+			return errors.New("ошибка получения карточек колонки")
+		}
+
+		return archiveCards(tx, actor, boardID, cards)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, card := range cards {
+		s.publish(events.CardArchived, actor, boardID, deletedPayload{ID: card.ID})
+	}
+
+	return len(cards), nil
+}
+
+// UnarchiveCards возвращает карточки из архива на доску. Без req.ColumnID каждая карточка
+// возвращается на прежнее место в своей колонке (или сразу после занявшей его),
+// с ним — в конец указанной колонки в порядке req.CardIDs.
+func (s *BoardService) UnarchiveCards(actor Actor, boardID string, req models.UnarchiveCardsRequest) ([]models.Card, error) {
+	if len(req.CardIDs) == 0 {
+		return nil, errors.New("не указаны карточки")
+	}
+
+	var cards []models.Card
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		var found []models.Card
+		if err := tx.Where("id IN ? AND board_id = ? AND archived_at IS NOT NULL", req.CardIDs, boardID).
+			Find(&found).Error; err != nil {
+			return errors.New("ошибка получения карточек")
+		}
+		byID := make(map[string]models.Card, len(found))
+		for _, card := range found {
+			byID[card.ID] = card
+		}
+
+		cards = make([]models.Card, 0, len(req.CardIDs))
+		for _, id := range req.CardIDs {
+			card, ok := byID[id]
+			if !ok {
+				return errors.New("карточка не найдена в архиве")
+			}
+			delete(byID, id)
+			cards = append(cards, card)
+		}
+
+		var lastRank string
+		if req.ColumnID != "" {
+			var count int64
+			if err := tx.Model(&models.Column{}).Where("id = ? AND board_id = ?", req.ColumnID, boardID).
+				Count(&count).Error; err != nil {
+				return errors.New("ошибка получения колонки")
+			}
+			if count == 0 {
+				return errors.New("колонка не найдена")
+			}
+
+			rank, err := rankAfterLast(rankedCards(tx, req.ColumnID))
+			if err != nil {
+				return errors.New("ошибка получения порядка карточек")
+			}
+			lastRank = rank
+		}
+
+		for i := range cards {
+			card := &cards[i]
+			before := cardSnapshot(card)
+
+			columnID, rank := card.ColumnID, card.Rank
+			if req.ColumnID != "" {
+				columnID = req.ColumnID
+				if i > 0 {
+					lastRank = lexorank.Between(lastRank, "")
+				}
+				rank = lastRank
+			} else {
+				var count int64
+				if err := tx.Model(&models.Column{}).Where("id = ?", columnID).Count(&count).Error; err != nil {
+					return errors.New("ошибка получения колонки")
+				}
+				if count == 0 {
+					return ErrArchivedColumnGone
+				}
+
+				free, err := freeRank(rankedCards(tx, columnID), card.Rank)
+				if err != nil {
+					return errors.New("ошибка получения порядка карточек")
+				}
+				rank = free
+			}
+
+			// Мягкий лимит возврату из архива не мешает
+			if _, err := checkWIPLimit(tx, columnID, 1); err != nil {
+				return err
+			}
+
+			if err := tx.Model(card).Updates(map[string]interface{}{
+				"archived_at": nil,
+				"column_id":   columnID,
+				"rank":        rank,
+				"version":     gorm.Expr("version + 1"),
+				"updated_by":  actor.UserID,
+			}).Error; err != nil {
+				if errors.Is(err, gorm.ErrDuplicatedKey) {
+					return err
+				}
+				return errors.New("ошибка возврата карточки из архива")
+			}
+			card.ColumnID, card.Rank = columnID, rank
+
+			changes := diffSnapshots(before, cardSnapshot(card))
+			changes["archived"] = models.FieldChange{Before: true, After: false}
+			if err := recordActivity(tx, actor, events.CardUnarchived, boardID, card.ID, columnID, changes); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Preload("Labels").Where("id IN ?", req.CardIDs).Order("rank ASC").Find(&cards).Error; err != nil {
+			return errors.New("ошибка перезагрузки карточек")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, card := range cards {
+		s.publish(events.CardUnarchived, actor, boardID, card)
+	}
+
+	return cards, nil
+}
+
+// archiveCards отправляет карточки в архив и записывает это в журнал в транзакции tx
+func archiveCards(tx *gorm.DB, actor Actor, boardID string, cards []models.Card) error {
+	if len(cards) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(cards))
+	for i := range cards {
+		ids[i] = cards[i].ID
+	}
+
+	// PostgreSQL хранит время с точностью до микросекунд
+	now := time.Now().Truncate(time.Microsecond)
+	if err := tx.Model(&models.Card{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"archived_at": now,
+		"version":     gorm.Expr("version + 1"),
+		"updated_by":  actor.UserID,
+	}).Error; err != nil {
+		return errors.New("ошибка архивации карточек")
+	}
+
+	for i := range cards {
+		changes := models.FieldChanges{"archived": {Before: false, After: true}}
+		if err := recordActivity(tx, actor, events.CardArchived, boardID, cards[i].ID, cards[i].ColumnID, changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
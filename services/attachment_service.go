@@ -0,0 +1,255 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"task-board/database"
+	"task-board/events"
+	"task-board/models"
+	"task-board/storage"
+)
+
+var (
+	// ErrAttachmentTooLarge возвращается, если файл больше допустимого размера
+	ErrAttachmentTooLarge = errors.New("файл слишком большой")
+	// ErrQuotaExceeded возвращается, если вложения доски превысят квоту
+	ErrQuotaExceeded = errors.New("превышена квота вложений доски")
+)
+
+// sniffLength — сколько первых байт файла нужно для определения типа содержимого
+const sniffLength = 512
+
+// AttachmentConfig содержит ограничения на размер вложений
+type AttachmentConfig struct {
+	MaxFileSize int64 // максимальный размер одного файла в байтах
+	BoardQuota  int64 // суммарный размер вложений одной доски в байтах
+}
+
+// GetAttachmentConfigFromEnv получает ограничения вложений из переменных окружения (в мегабайтах)
+func GetAttachmentConfigFromEnv() AttachmentConfig {
+	return AttachmentConfig{
+		MaxFileSize: getEnvMegabytes("ATTACHMENT_MAX_SIZE_MB", 10),
+		BoardQuota:  getEnvMegabytes("ATTACHMENT_BOARD_QUOTA_MB", 100),
+	}
+}
+
+type AttachmentService struct {
+	publisher
+	db     *gorm.DB
+	store  storage.Storage
+	config AttachmentConfig
+}
+
+func NewAttachmentService(broker events.Broker, store storage.Storage, config AttachmentConfig) *AttachmentService {
+	return &AttachmentService{
+		publisher: publisher{broker: broker},
+		db:        database.DB,
+		store:     store,
+		config:    config,
+	}
+}
+
+// List возвращает вложения карточки от старых к новым
+func (s *AttachmentService) List(boardID, cardID string) ([]models.Attachment, error) {
+	if _, err := loadBoardCard(s.db, boardID, cardID); err != nil {
+		return nil, err
+	}
+
+	attachments := []models.Attachment{}
+	if err := s.db.Where("card_id = ?", cardID).Order("created_at ASC").Find(&attachments).Error; err != nil {
+		return nil, errors.New("ошибка получения вложений")
+	}
+
+	return attachments, nil
+}
+
+// Upload сохраняет файл в хранилище и прикрепляет его к карточке.
+// Тип содержимого определяется по первым байтам файла, а не по данным клиента.
+func (s *AttachmentService) Upload(actor Actor, boardID, cardID, fileName string, r io.Reader, size int64) (*models.Attachment, error) {
+	if size > s.config.MaxFileSize {
+		return nil, ErrAttachmentTooLarge
+	}
+
+	fileName = strings.TrimSpace(filepath.Base(strings.ReplaceAll(fileName, "\\", "/")))
+	if fileName == "" || fileName == "." || fileName == "/" {
+		fileName = "file"
+	}
+
+	// Быстрая проверка до загрузки; окончательная — в транзакции ниже
+	if _, err := loadBoardCard(s.db, boardID, cardID); err != nil {
+		return nil, err
+	}
+	if err := s.checkQuota(s.db, boardID, size); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, errors.New("ошибка чтения файла")
+	}
+	head = head[:n]
+
+	attachment := &models.Attachment{
+		ID:          generateID(),
+		BoardID:     boardID,
+		CardID:      cardID,
+		FileName:    fileName,
+		ContentType: http.DetectContentType(head),
+		Size:        size,
+		UploadedBy:  actor.UserID,
+	}
+	attachment.StorageKey = boardID + "/" + attachment.ID
+
+	if err := s.store.Put(attachment.StorageKey, io.MultiReader(bytes.NewReader(head), r), size, attachment.ContentType); err != nil {
+		log.Println("Ошибка сохранения вложения:", err)
+		return nil, errors.New("ошибка сохранения файла")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Блокируем доску, чтобы параллельные загрузки не превысили квоту вместе
+		var board models.Board
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&board, "id = ?", boardID).Error; err != nil {
+			return errors.New("доска не найдена")
+		}
+
+		if _, err := loadBoardCard(tx, boardID, cardID); err != nil {
+			return err
+		}
+		if err := s.checkQuota(tx, boardID, size); err != nil {
+			return err
+		}
+
+		if err := tx.Create(attachment).Error; err != nil {
+			return errors.New("ошибка создания вложения")
+		}
+
+		changes := models.FieldChanges{
+			"attachment_id": {After: attachment.ID},
+			"file_name":     {After: attachment.FileName},
+			"size":          {After: attachment.Size},
+		}
+		return recordActivity(tx, actor, events.AttachmentCreated, boardID, cardID, "", changes)
+	})
+	if err != nil {
+		removeBlobs(s.store, []string{attachment.StorageKey})
+		return nil, err
+	}
+
+	s.publish(events.AttachmentCreated, actor, boardID, attachment)
+
+	return attachment, nil
+}
+
+// Open возвращает вложение и его содержимое. Вызывающий должен закрыть содержимое.
+func (s *AttachmentService) Open(boardID, cardID, attachmentID string) (*models.Attachment, io.ReadCloser, error) {
+	var attachment models.Attachment
+	if err := loadAttachment(s.db, boardID, cardID, attachmentID, &attachment); err != nil {
+		return nil, nil, err
+	}
+
+	content, err := s.store.Open(attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, errors.New("файл вложения не найден")
+	}
+	if err != nil {
+		log.Println("Ошибка чтения вложения:", err)
+		return nil, nil, errors.New("ошибка чтения файла")
+	}
+
+	return &attachment, content, nil
+}
+
+// Delete удаляет вложение и его содержимое из хранилища
+func (s *AttachmentService) Delete(actor Actor, boardID, cardID, attachmentID string) error {
+	var attachment models.Attachment
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadAttachment(tx, boardID, cardID, attachmentID, &attachment); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&attachment).Error; err != nil {
+			return errors.New("ошибка удаления вложения")
+		}
+
+		changes := models.FieldChanges{
+			"attachment_id": {Before: attachment.ID},
+			"file_name":     {Before: attachment.FileName},
+			"size":          {Before: attachment.Size},
+		}
+		return recordActivity(tx, actor, events.AttachmentDeleted, boardID, cardID, "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	removeBlobs(s.store, []string{attachment.StorageKey})
+	s.publish(events.AttachmentDeleted, actor, boardID, deletedPayload{ID: attachmentID})
+
+	return nil
+}
+
+// checkQuota проверяет, что файл размера size поместится в квоту доски
+func (s *AttachmentService) checkQuota(tx *gorm.DB, boardID string, size int64) error {
+	var used int64
+	if err := tx.Model(&models.Attachment{}).Select("COALESCE(SUM(size), 0)").
+		Where("board_id = ?", boardID).Scan(&used).Error; err != nil {
+		return errors.New("ошибка проверки квоты вложений")
+	}
+
+	if used+size > s.config.BoardQuota {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// loadAttachment загружает вложение карточки текущей доски
+func loadAttachment(tx *gorm.DB, boardID, cardID, attachmentID string, attachment *models.Attachment) error {
+	if err := tx.First(attachment, "id = ? AND card_id = ? AND board_id = ?", attachmentID, cardID, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("вложение не найдено")
+		}
+		return errors.New("ошибка получения вложения")
+	}
+	return nil
+}
+
+// attachmentKeys возвращает ключи хранилища вложений, подходящих под условие.
+// Вызывается в транзакции до удаления строк, которые удалят вложения каскадом.
+func attachmentKeys(tx *gorm.DB, query string, args ...interface{}) ([]string, error) {
+	var keys []string
+	if err := tx.Model(&models.Attachment{}).Where(query, args...).Pluck("storage_key", &keys).Error; err != nil {
+		return nil, errors.New("ошибка получения вложений")
+	}
+	return keys, nil
+}
+
+// removeBlobs удаляет содержимое вложений из хранилища после удаления их записей.
+// Ошибки только логируются: записи уже удалены, а файл без записи недоступен.
+func removeBlobs(store storage.Storage, keys []string) {
+	for _, key := range keys {
+		if err := store.Delete(key); err != nil {
+			log.Printf("Ошибка удаления файла %s из хранилища: %v", key, err)
+		}
+	}
+}
+
+// getEnvMegabytes читает размер в мегабайтах и возвращает его в байтах
+func getEnvMegabytes(key string, defaultValue int64) int64 {
+	if value, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil && value > 0 {
+		return value << 20
+	}
+	return defaultValue << 20
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"task-board/models"
+)
+
+const (
+	// maxFilterLength ограничивает длину фильтра доски и сохраненных представлений
+	maxFilterLength = 1000
+	// maxDueOffset ограничивает относительный срок в условии due, чтобы не переполнить time.Duration
+	maxDueOffset = 10000
+)
+
+// filterTerm — условие фильтра доски «key:value». Условие без ключа ищет слово
+// в тексте карточки; negate исключает подходящие карточки.
+type filterTerm struct {
+	negate bool
+	key    string
+	value  string
+}
+
+// filterKeys — ключи условий, которые понимает фильтр доски
+var filterKeys = map[string]bool{
+	"assignee": true,
+	"label":    true,
+	"column":   true,
+	"priority": true,
+	"due":      true,
+}
+
+// parseBoardFilter разбирает фильтр доски вида `assignee:ivan label:bug due:<7d -column:done`.
+// Условия разделяются пробелами и объединяются через И; значения с пробелами берутся
+// в двойные кавычки, а "-" перед условием исключает подходящие карточки.
+func parseBoardFilter(text string) ([]filterTerm, error) {
+	if utf8.RuneCountInString(text) > maxFilterLength {
+		return nil, &ValidationError{Message: "фильтр длиннее 1000 символов"}
+	}
+
+	runes := []rune(text)
+	var terms []filterTerm
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var term filterTerm
+		if runes[i] == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			term.negate = true
+			i++
+		}
+
+		var buf strings.Builder
+		keyDone, quoted := false, false
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			switch r := runes[i]; {
+			case r == '"':
+				end := i + 1
+				for end < len(runes) && runes[end] != '"' {
+					end++
+				}
+				if end == len(runes) {
+					return nil, &ValidationError{Message: "в фильтре не закрыта кавычка"}
+				}
+				buf.WriteString(string(runes[i+1 : end]))
+				quoted = true
+				i = end
+			case r == ':' && !keyDone && !quoted && buf.Len() > 0:
+				term.key = strings.ToLower(buf.String())
+				buf.Reset()
+				keyDone = true
+			default:
+				buf.WriteRune(r)
+			}
+			i++
+		}
+		term.value = buf.String()
+
+		if keyDone && !filterKeys[term.key] {
+			return nil, &ValidationError{Message: "неизвестное условие фильтра «" + term.key + "»: допустимы assignee, label, column, priority и due"}
+		}
+		if term.value == "" {
+			if keyDone {
+				return nil, &ValidationError{Message: "не указано значение условия «" + term.key + "»"}
+			}
+			continue
+		}
+		terms = append(terms, term)
+	}
+
+	return terms, nil
+}
+
+// applyBoardFilter добавляет к запросу карточек доски условия фильтра.
+// Относительные сроки в условиях due отсчитываются от now.
+func applyBoardFilter(query *gorm.DB, boardID string, terms []filterTerm, now time.Time) (*gorm.DB, error) {
+	for _, term := range terms {
+		condition, args, err := filterCondition(boardID, term, now)
+		if err != nil {
+			return nil, err
+		}
+
+		// COALESCE: исключение не должно терять карточки, для которых условие дает NULL,
+		// например карточки без дедлайна в -due:<7d
+		if term.negate {
+			query = query.Where("NOT COALESCE(("+condition+"), false)", args...)
+		} else {
+			query = query.Where(condition, args...)
+		}
+	}
+	return query, nil
+}
+
+// filterCondition переводит условие фильтра в SQL-условие по таблице cards
+func filterCondition(boardID string, term filterTerm, now time.Time) (string, []interface{}, error) {
+	value := term.value
+
+	switch term.key {
+	case "":
+		return "search_vector @@ plainto_tsquery('russian', ?)", []interface{}{value}, nil
+
+	case "assignee":
+		if value == "none" {
+			return "assignee = ''", nil, nil
+		}
+		return "lower(assignee) = lower(?)", []interface{}{value}, nil
+
+	case "label":
+		if value == "none" {
+			return "NOT EXISTS (SELECT 1 FROM card_labels WHERE card_labels.card_id = cards.id)", nil, nil
+		}
+		return `EXISTS (SELECT 1 FROM card_labels JOIN labels ON labels.id = card_labels.label_id
+			WHERE card_labels.card_id = cards.id AND lower(labels.name) = lower(?))`, []interface{}{value}, nil
+
+	case "column":
+		return `column_id IN (SELECT id FROM columns WHERE board_id = ? AND deleted_at IS NULL
+			AND (id = ? OR lower(name) = lower(?)))`, []interface{}{boardID, value, value}, nil
+
+	case "priority":
+		if value == "none" {
+			return "priority = ''", nil, nil
+		}
+		priority := models.Priority(strings.ToLower(value))
+		if !priority.Valid() {
+			return "", nil, &ValidationError{Message: "неизвестный приоритет «" + value + "» в фильтре"}
+		}
+		return "priority = ?", []interface{}{priority}, nil
+
+	case "due":
+		return dueCondition(value, now)
+	}
+
+	return "", nil, &ValidationError{Message: "неизвестное условие фильтра «" + term.key + "»"}
+}
+
+// dueCondition разбирает условие на дедлайн: none, overdue, today, дату YYYY-MM-DD
+// или сравнение с датой либо сроком от текущего момента (<7d, >=2w, >-12h, <2026-01-31)
+func dueCondition(value string, now time.Time) (string, []interface{}, error) {
+	switch strings.ToLower(value) {
+	case "none":
+		return "deadline IS NULL", nil, nil
+	case "overdue":
+		return "deadline < ?", []interface{}{now}, nil
+	case "today":
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return "deadline >= ? AND deadline < ?", []interface{}{start, start.AddDate(0, 0, 1)}, nil
+	}
+
+	op := ""
+	for _, candidate := range []string{"<=", ">=", "<", ">"} {
+		if strings.HasPrefix(value, candidate) {
+			op = candidate
+			value = value[len(candidate):]
+			break
+		}
+	}
+
+	invalid := &ValidationError{Message: "неверное условие due:" + op + value +
+		": ожидаются none, overdue, today, дата YYYY-MM-DD или срок вроде <7d"}
+
+	if day, err := time.Parse(customFieldDateLayout, value); err == nil {
+		next := day.AddDate(0, 0, 1)
+		switch op {
+		case "":
+			return "deadline >= ? AND deadline < ?", []interface{}{day, next}, nil
+		case "<":
+			return "deadline < ?", []interface{}{day}, nil
+		case "<=":
+			return "deadline < ?", []interface{}{next}, nil
+		case ">":
+			return "deadline >= ?", []interface{}{next}, nil
+		default:
+			return "deadline >= ?", []interface{}{day}, nil
+		}
+	}
+
+	// Относительный срок требует сравнения: due:7d неоднозначно
+	if op == "" || len(value) < 2 {
+		return "", nil, invalid
+	}
+	units := map[byte]time.Duration{'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return "", nil, invalid
+	}
+	amount, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || amount > maxDueOffset || amount < -maxDueOffset {
+		return "", nil, invalid
+	}
+
+	return "deadline " + op + " ?", []interface{}{now.Add(time.Duration(amount) * unit)}, nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBoardFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []filterTerm
+		wantErr string
+	}{
+		{
+			name: "пустой фильтр",
+			text: "   ",
+		},
+		{
+			name: "условия и слова",
+			text: "assignee:ivan  label:bug отчет",
+			want: []filterTerm{
+				{key: "assignee", value: "ivan"},
+				{key: "label", value: "bug"},
+				{value: "отчет"},
+			},
+		},
+		{
+			name: "ключ без учета регистра",
+			text: "Priority:High",
+			want: []filterTerm{{key: "priority", value: "High"}},
+		},
+		{
+			name: "значение в кавычках",
+			text: `column:"In progress" label:"срочно: сегодня"`,
+			want: []filterTerm{
+				{key: "column", value: "In progress"},
+				{key: "label", value: "срочно: сегодня"},
+			},
+		},
+		{
+			name: "двоеточие после кавычек не выделяет ключ",
+			text: `"a b":c`,
+			want: []filterTerm{{value: "a b:c"}},
+		},
+		{
+			name: "отрицание",
+			text: "-column:done -черновик",
+			want: []filterTerm{
+				{negate: true, key: "column", value: "done"},
+				{negate: true, value: "черновик"},
+			},
+		},
+		{
+			name: "одиночный минус — слово",
+			text: "- due:<7d",
+			want: []filterTerm{
+				{value: "-"},
+				{key: "due", value: "<7d"},
+			},
+		},
+		{
+			name:    "неизвестный ключ",
+			text:    "status:open",
+			wantErr: "неизвестное условие фильтра «status»",
+		},
+		{
+			name:    "пустое значение",
+			text:    "label:",
+			wantErr: "не указано значение условия «label»",
+		},
+		{
+			name:    "незакрытая кавычка",
+			text:    `label:"bug`,
+			wantErr: "в фильтре не закрыта кавычка",
+		},
+		{
+			name: "предельная длина в символах",
+			text: strings.Repeat("я", maxFilterLength),
+			want: []filterTerm{{value: strings.Repeat("я", maxFilterLength)}},
+		},
+		{
+			name:    "длиннее предела",
+			text:    strings.Repeat("я", maxFilterLength+1),
+			wantErr: "фильтр длиннее 1000 символов",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBoardFilter(tt.text)
+			if tt.wantErr != "" {
+				checkValidationError(t, err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseBoardFilter(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDueCondition(t *testing.T) {
+	loc := time.FixedZone("MSK", 3*60*60)
+	now := time.Date(2026, 1, 15, 13, 30, 0, 0, loc)
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		value     string
+		condition string
+		args      []interface{}
+		wantErr   bool
+	}{
+		{"без дедлайна", "none", "deadline IS NULL", nil, false},
+		{"просрочено", "OVERDUE", "deadline < ?", []interface{}{now}, false},
+		{
+			"сегодня", "today", "deadline >= ? AND deadline < ?",
+			[]interface{}{time.Date(2026, 1, 15, 0, 0, 0, 0, loc), time.Date(2026, 1, 16, 0, 0, 0, 0, loc)}, false,
+		},
+		{"дата", "2026-01-31", "deadline >= ? AND deadline < ?", []interface{}{day(31), time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}, false},
+		{"до даты", "<2026-01-31", "deadline < ?", []interface{}{day(31)}, false},
+		{"до даты включительно", "<=2026-01-31", "deadline < ?", []interface{}{time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)}, false},
+		{"после даты", ">2026-01-20", "deadline >= ?", []interface{}{day(21)}, false},
+		{"с даты", ">=2026-01-20", "deadline >= ?", []interface{}{day(20)}, false},
+		{"в ближайшие 7 дней", "<7d", "deadline < ?", []interface{}{now.Add(7 * 24 * time.Hour)}, false},
+		{"через 2 недели и позже", ">=2w", "deadline >= ?", []interface{}{now.Add(14 * 24 * time.Hour)}, false},
+		{"отрицательный срок", ">-12h", "deadline > ?", []interface{}{now.Add(-12 * time.Hour)}, false},
+		{"предельный срок", "<10000w", "deadline < ?", []interface{}{now.Add(10000 * 7 * 24 * time.Hour)}, false},
+		{"срок больше предела", "<10001w", "", nil, true},
+		{"отрицательный срок больше предела", ">-10001d", "", nil, true},
+		{"переполнение числа", "<99999999999999999999h", "", nil, true},
+		{"срок без сравнения", "7d", "", nil, true},
+		{"неизвестная единица", "<7m", "", nil, true},
+		{"нет числа", "<d", "", nil, true},
+		{"неверная дата", "2026-02-30", "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, args, err := dueCondition(tt.value, now)
+			if tt.wantErr {
+				checkValidationError(t, err, "неверное условие due:")
+				return
+			}
+			if err != nil {
+				t.Fatalf("неожиданная ошибка: %v", err)
+			}
+			if condition != tt.condition {
+				t.Fatalf("условие %q, want %q", condition, tt.condition)
+			}
+			if len(args) != len(tt.args) {
+				t.Fatalf("аргументы %v, want %v", args, tt.args)
+			}
+			for i := range args {
+				if !args[i].(time.Time).Equal(tt.args[i].(time.Time)) {
+					t.Fatalf("аргумент %d = %v, want %v", i, args[i], tt.args[i])
+				}
+			}
+		})
+	}
+}
+
+// checkValidationError проверяет, что err — ValidationError с сообщением, начинающимся с prefix
+func checkValidationError(t *testing.T, err error, prefix string) {
+	t.Helper()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("ожидалась ValidationError, получено %v", err)
+	}
+	if !strings.HasPrefix(validationErr.Message, prefix) {
+		t.Fatalf("ошибка %q, ожидалось начало %q", validationErr.Message, prefix)
+	}
+}
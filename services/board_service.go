@@ -4,31 +4,94 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
+	"log"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 	"task-board/database"
+	"task-board/events"
+	"task-board/lexorank"
 	"task-board/models"
+	"task-board/storage"
 )
 
 type BoardService struct {
-	db *gorm.DB
+	publisher
+	db    *gorm.DB
+	store storage.Storage
 }
 
-func NewBoardService() *BoardService {
+// Actor описывает, кто выполняет изменение на доске.
+// Пустой UserID означает гостевой доступ по паролю доски,
+// Role — роль на доске, определенная middleware.RequireRole.
+type Actor struct {
+	UserID string
+	Role   models.Role
+}
+
+// IsGuest сообщает, что действие выполняется без учетной записи
+func (a Actor) IsGuest() bool {
+	return a.UserID == ""
+}
+
+func NewBoardService(broker events.Broker, store storage.Storage) *BoardService {
 	return &BoardService{
-		db: database.DB,
+		publisher: publisher{broker: broker},
+		db:        database.DB,
+		store:     store,
 	}
 }
 
-// CreateBoard создает новую доску с тремя колонками по умолчанию
-func (s *BoardService) CreateBoard(name, password string) (*models.Board, error) {
+// ErrColumnNotEmpty возвращается при удалении колонки с карточками без выбора режима
+var ErrColumnNotEmpty = errors.New("в колонке есть карточки: укажите режим удаления mode (move, archive или delete)")
+
+// deletedPayload — данные события об удалении
+type deletedPayload struct {
+	ID string `json:"id"`
+}
+
+// columnDeletedPayload — данные события об удалении колонки с режимом обработки её карточек
+type columnDeletedPayload struct {
+	ID             string                  `json:"id"`
+	Mode           models.ColumnDeleteMode `json:"mode,omitempty"`
+	TargetColumnID string                  `json:"target_column_id,omitempty"`
+}
+
+// publisher рассылает события сервисов, изменяющих доску
+type publisher struct {
+	broker events.Broker
+}
+
+// publish рассылает событие об изменении доски после успешной записи в БД
+func (p publisher) publish(eventType events.Type, actor Actor, boardID string, data interface{}) {
+	event := events.Event{
+		Type:    eventType,
+		BoardID: boardID,
+		ActorID: actor.UserID,
+		Data:    data,
+		At:      time.Now(),
+	}
+
+	if err := p.broker.Publish(event); err != nil {
+		log.Println("Ошибка публикации события:", err)
+	}
+}
+
+// CreateBoard создает новую доску с тремя колонками по умолчанию.
+// Если доску создает пользователь, он становится её участником,
+// а гостевой доступ включается только при заданном пароле.
+func (s *BoardService) CreateBoard(actor Actor, name, password string) (*models.Board, error) {
 	id := generateID()
 
 	// Хешируем пароль
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return nil, errors.New("ошибка хеширования пароля")
+	var passwordHash []byte
+	if password != "" {
+		var err error
+		passwordHash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, errors.New("ошибка хеширования пароля")
+		}
 	}
 
 	// Создаем доску
@@ -36,20 +99,30 @@ func (s *BoardService) CreateBoard(name, password string) (*models.Board, error)
 		ID:           id,
 		Name:         name,
 		PasswordHash: string(passwordHash),
+		GuestAccess:  password != "",
 	}
 
 	// Используем транзакцию для создания доски и колонок
-	err = s.db.Transaction(func(tx *gorm.DB) error {
-		// Сохраняем доску
-		if err := tx.Create(board).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Сохраняем доску (Select, чтобы GuestAccess=false не заменился значением по умолчанию)
+		if err := tx.Select("*").Create(board).Error; err != nil {
 			return err
 		}
 
+		// Создатель доски становится её участником
+		if !actor.IsGuest() {
+			member := &models.BoardMember{BoardID: id, UserID: actor.UserID, Role: models.RoleOwner}
+			if err := tx.Create(member).Error; err != nil {
+				return err
+			}
+		}
+
 		// Создаем три колонки по умолчанию
+		ranks := lexorank.Spread(3)
 		defaultColumns := []models.Column{
-			{ID: generateID(), BoardID: id, Name: "Актуальные задачи", OrderNum: 1},
-			{ID: generateID(), BoardID: id, Name: "В работе", OrderNum: 2},
-			{ID: generateID(), BoardID: id, Name: "Выполнено", OrderNum: 3},
+			{ID: generateID(), BoardID: id, Name: "Актуальные задачи", Rank: ranks[0], CreatedBy: actor.UserID},
+			{ID: generateID(), BoardID: id, Name: "В работе", Rank: ranks[1], CreatedBy: actor.UserID},
+			{ID: generateID(), BoardID: id, Name: "Выполнено", Rank: ranks[2], CreatedBy: actor.UserID},
 		}
 
 		for _, col := range defaultColumns {
@@ -58,7 +131,8 @@ func (s *BoardService) CreateBoard(name, password string) (*models.Board, error)
 			}
 		}
 
-		return nil
+		changes := models.FieldChanges{"name": {After: name}}
+		return recordActivity(tx, actor, events.BoardCreated, id, "", "", changes)
 	})
 
 	if err != nil {
@@ -66,30 +140,128 @@ func (s *BoardService) CreateBoard(name, password string) (*models.Board, error)
 	}
 
 	// Загружаем доску с колонками для ответа
-	return s.GetBoard(id)
+	return s.GetBoard(id, models.BoardQuery{})
 }
 
-// GetBoard получает доску по ID с колонками и карточками
-func (s *BoardService) GetBoard(id string) (*models.Board, error) {
+// GetBoard получает доску по ID с колонками и карточками, отобранными фильтрами q
+func (s *BoardService) GetBoard(id string, q models.BoardQuery) (*models.Board, error) {
 	var board models.Board
 
+	fields, err := loadCustomFields(s.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Условия сохраненного представления дополняются условиями из запроса.
+	// Фильтры разбираются по отдельности, чтобы ограничение длины действовало
+	// на каждый из них, а не на их склейку.
+	terms, err := parseBoardFilter(q.Query)
+	if err != nil {
+		return nil, err
+	}
+	if q.View != "" {
+		var view models.SavedView
+		if err := loadSavedView(s.db, id, q.View, &view); err != nil {
+			return nil, &ValidationError{Message: err.Error()}
+		}
+		viewTerms, err := parseBoardFilter(view.Query)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(viewTerms, terms...)
+	}
+
+	cards, err := applyCustomFieldFilters(s.db.Where("archived_at IS NULL"), fields, q.Filters)
+	if err != nil {
+		return nil, err
+	}
+	if cards, err = applyBoardFilter(cards, id, terms, time.Now()); err != nil {
+		return nil, err
+	}
+
 	// Получаем доску с колонками и карточками
-	if err := s.db.Preload("Columns.Cards", func(db *gorm.DB) *gorm.DB {
-		return db.Order("order_num ASC")
+	if err := s.db.Preload("Columns.Cards.Labels", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name ASC")
+	}).Preload("Columns.Cards", func(db *gorm.DB) *gorm.DB {
+		return db.Where(cards).Order("rank ASC")
 	}).Preload("Columns", func(db *gorm.DB) *gorm.DB {
-		return db.Order("order_num ASC")
+		return db.Order("rank ASC")
 	}).First(&board, "id = ?", id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("доска не найдена")
 		}
 		return nil, errors.New("ошибка получения доски")
 	}
+	board.CustomFields = fields
+
+	if err := s.fillCardSummaries(&board); err != nil {
+		return nil, err
+	}
+
+	// WIP-лимиты считаются по всем карточкам колонок, а не только по отобранным
+	var counts map[string]int
+	if len(q.Filters) > 0 || len(terms) > 0 {
+		if counts, err = countColumnCards(s.db, id); err != nil {
+			return nil, err
+		}
+	}
+	markOverWIPLimit(&board, counts)
+	sortColumnCards(&board)
+
+	if err := buildLanes(s.db, &board); err != nil {
+		return nil, err
+	}
 
 	return &board, nil
 }
 
-// CreateColumn создает новую колонку
-func (s *BoardService) CreateColumn(boardID string, req models.CreateColumnRequest) (*models.Column, error) {
+// fillCardSummaries подставляет в карточки доски число неудаленных комментариев
+// и сводку выполнения чек-листов
+func (s *BoardService) fillCardSummaries(board *models.Board) error {
+	type commentCount struct {
+		CardID string
+		Count  int64
+	}
+	var counts []commentCount
+	if err := s.db.Model(&models.Comment{}).Select("card_id, COUNT(*) AS count").
+		Where("board_id = ?", board.ID).Group("card_id").Scan(&counts).Error; err != nil {
+		return errors.New("ошибка получения числа комментариев")
+	}
+
+	type checklistCount struct {
+		CardID string
+		Done   int64
+		Total  int64
+	}
+	var progress []checklistCount
+	if err := s.db.Model(&models.ChecklistItem{}).
+		Select("checklists.card_id, COUNT(*) FILTER (WHERE checklist_items.done) AS done, COUNT(*) AS total").
+		Joins("JOIN checklists ON checklists.id = checklist_items.checklist_id").
+		Where("checklists.board_id = ?", board.ID).Group("checklists.card_id").Scan(&progress).Error; err != nil {
+		return errors.New("ошибка получения прогресса чек-листов")
+	}
+
+	commentsByCard := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		commentsByCard[c.CardID] = c.Count
+	}
+	progressByCard := make(map[string]*models.ChecklistProgress, len(progress))
+	for _, p := range progress {
+		progressByCard[p.CardID] = &models.ChecklistProgress{Done: p.Done, Total: p.Total}
+	}
+
+	for i := range board.Columns {
+		for j := range board.Columns[i].Cards {
+			card := &board.Columns[i].Cards[j]
+			card.CommentCount = commentsByCard[card.ID]
+			card.ChecklistProgress = progressByCard[card.ID]
+		}
+	}
+	return nil
+}
+
+// CreateColumn создает новую колонку в конце доски
+func (s *BoardService) CreateColumn(actor Actor, boardID string, req models.CreateColumnRequest) (*models.Column, error) {
 	// Проверяем, что доска существует
 	var board models.Board
 	if err := s.db.First(&board, "id = ?", boardID).Error; err != nil {
@@ -99,69 +271,280 @@ func (s *BoardService) CreateColumn(boardID string, req models.CreateColumnReque
 		return nil, errors.New("ошибка получения доски")
 	}
 
-	// Получаем следующий порядковый номер
-	var maxOrder int64
-	s.db.Model(&models.Column{}).Where("board_id = ?", boardID).
-		Select("COALESCE(MAX(order_num), 0)").Scan(&maxOrder)
-
 	column := &models.Column{
-		ID:       generateID(),
-		BoardID:  boardID,
-		Name:     req.Name,
-		OrderNum: int(maxOrder) + 1,
+		ID:        generateID(),
+		BoardID:   boardID,
+		Name:      req.Name,
+		CreatedBy: actor.UserID,
 	}
 
-	if err := s.db.Create(column).Error; err != nil {
-		return nil, errors.New("ошибка создания колонки")
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		// Ранг после последней колонки доски
+		rank, err := rankAfterLast(tx.Model(&models.Column{}).Where("board_id = ?", boardID))
+		if err != nil {
+			return errors.New("ошибка получения порядка колонок")
+		}
+		column.Rank = rank
+
+		if err := tx.Create(column).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка создания колонки")
+		}
+
+		return recordActivity(tx, actor, events.ColumnCreated, boardID, "", column.ID,
+			diffSnapshots(nil, columnSnapshot(column)))
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	s.publish(events.ColumnCreated, actor, boardID, column)
+
 	return column, nil
 }
 
 // UpdateColumn обновляет колонку
-func (s *BoardService) UpdateColumn(boardID, columnID string, req models.UpdateColumnRequest) (*models.Column, error) {
+func (s *BoardService) UpdateColumn(actor Actor, boardID, columnID string, req models.UpdateColumnRequest) (*models.Column, error) {
 	var column models.Column
 
-	if err := s.db.Where("id = ? AND board_id = ?", columnID, boardID).First(&column).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("колонка не найдена")
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND board_id = ?", columnID, boardID).First(&column).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("колонка не найдена")
+			}
+			return errors.New("ошибка получения колонки")
 		}
-		return nil, errors.New("ошибка получения колонки")
+		before := columnSnapshot(&column)
+
+		err := updateVersioned(tx, &column, req.Version, map[string]interface{}{"name": req.Name})
+		if errors.Is(err, errVersionMismatch) {
+			return err
+		}
+		if err != nil {
+			return errors.New("ошибка обновления колонки")
+		}
+
+		if err := tx.First(&column, "id = ?", columnID).Error; err != nil {
+			return errors.New("ошибка перезагрузки колонки")
+		}
+
+		return recordActivity(tx, actor, events.ColumnUpdated, boardID, "", columnID,
+			diffSnapshots(before, columnSnapshot(&column)))
+	})
+	if errors.Is(err, errVersionMismatch) {
+		return nil, s.columnConflict(columnID)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.db.Model(&column).Update("name", req.Name).Error; err != nil {
-		return nil, errors.New("ошибка обновления колонки")
+	s.publish(events.ColumnUpdated, actor, boardID, column)
+
+	return &column, nil
+}
+
+// MoveColumn перемещает колонку на позицию Order (начиная с 1).
+// Меняется только ранг самой колонки, остальные колонки не затрагиваются.
+func (s *BoardService) MoveColumn(actor Actor, boardID, columnID string, req models.MoveColumnRequest) (*models.Column, error) {
+	var column models.Column
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND board_id = ?", columnID, boardID).First(&column).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("колонка не найдена")
+			}
+			return errors.New("ошибка получения колонки")
+		}
+		before := columnSnapshot(&column)
+
+		// Ранг между соседями новой позиции среди остальных колонок
+		rank, err := rankForPosition(tx.Model(&models.Column{}).
+			Where("board_id = ? AND id <> ?", boardID, columnID), req.Order)
+		if err != nil {
+			return errors.New("ошибка получения порядка колонок")
+		}
+
+		err = updateVersioned(tx, &column, req.Version, map[string]interface{}{"rank": rank})
+		if errors.Is(err, gorm.ErrDuplicatedKey) || errors.Is(err, errVersionMismatch) {
+			return err
+		}
+		if err != nil {
+			return errors.New("ошибка перемещения колонки")
+		}
+
+		if err := tx.First(&column, "id = ?", columnID).Error; err != nil {
+			return errors.New("ошибка перезагрузки колонки")
+		}
+
+		return recordActivity(tx, actor, events.ColumnMoved, boardID, "", columnID,
+			diffSnapshots(before, columnSnapshot(&column)))
+	})
+	if errors.Is(err, errVersionMismatch) {
+		return nil, s.columnConflict(columnID)
+	}
+	if err != nil {
+		return nil, err
 	}
 
+	s.publish(events.ColumnMoved, actor, boardID, column)
+
 	return &column, nil
 }
 
-// DeleteColumn удаляет колонку (и все её карточки)
-func (s *BoardService) DeleteColumn(boardID, columnID string) error {
-	result := s.db.Where("id = ? AND board_id = ?", columnID, boardID).Delete(&models.Column{})
+// DeleteColumn перемещает колонку в корзину. Что станет с её карточками, задает q.Mode:
+// перенос в конец колонки q.TargetColumnID, архивация или удаление в корзину вместе с колонкой.
+// Колонку с карточками без режима удалить нельзя. Архивные карточки остаются в архиве
+// (при переносе переходят в целевую колонку).
+func (s *BoardService) DeleteColumn(actor Actor, boardID, columnID string, q models.DeleteColumnQuery) error {
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		var column models.Column
+		if err := tx.Where("id = ? AND board_id = ?", columnID, boardID).First(&column).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("колонка не найдена")
+			}
+			return errors.New("ошибка получения колонки")
+		}
+
+		var cards []models.Card
+		if err := rankedCards(tx, columnID).Preload("Labels").Order("rank ASC").Find(&cards).Error; err != nil {
+			return errors.New("ошибка получения карточек колонки")
+		}
+		if len(cards) > 0 && q.Mode == "" {
+			return ErrColumnNotEmpty
+		}
+
+		// PostgreSQL хранит время с точностью до микросекунд
+		now := time.Now().Truncate(time.Microsecond)
+
+		switch q.Mode {
+		case "":
+		case models.ColumnDeleteMove:
+			if err := moveColumnCards(tx, actor, boardID, columnID, q.TargetColumnID, cards); err != nil {
+				return err
+			}
+		case models.ColumnDeleteArchive:
+			if err := archiveCards(tx, actor, boardID, cards); err != nil {
+				return err
+			}
+		case models.ColumnDeleteCards:
+			if err := tx.Model(&models.Card{}).Where("column_id = ? AND archived_at IS NULL", columnID).
+				Update("deleted_at", now).Error; err != nil {
+				return errors.New("ошибка удаления карточек колонки")
+			}
+			for i := range cards {
+				if err := recordActivity(tx, actor, events.CardDeleted, boardID, cards[i].ID, columnID,
+					diffSnapshots(cardSnapshot(&cards[i]), nil)); err != nil {
+					return err
+				}
+			}
+		default:
+			return errors.New("неизвестный режим удаления колонки: допустимы move, archive и delete")
+		}
+
+		if err := tx.Model(&column).Update("deleted_at", now).Error; err != nil {
+			return errors.New("ошибка удаления колонки")
+		}
+
+		changes := diffSnapshots(columnSnapshot(&column), nil)
+		if len(cards) > 0 {
+			changes["cards"] = models.FieldChange{Before: len(cards), After: string(q.Mode)}
+		}
+		return recordActivity(tx, actor, events.ColumnDeleted, boardID, "", columnID, changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.ColumnDeleted, actor, boardID, columnDeletedPayload{
+		ID:             columnID,
+		Mode:           q.Mode,
+		TargetColumnID: q.TargetColumnID,
+	})
+
+	return nil
+}
+
+// moveColumnCards переносит карточки удаляемой колонки в конец колонки targetID,
+// сохраняя их порядок. Архивные карточки переходят в целевую колонку без рангов.
+func moveColumnCards(tx *gorm.DB, actor Actor, boardID, columnID, targetID string, cards []models.Card) error {
+	if targetID == "" {
+		return errors.New("укажите колонку target_column_id для переноса карточек")
+	}
+	if targetID == columnID {
+		return errors.New("нельзя перенести карточки в удаляемую колонку")
+	}
 
-	if result.Error != nil {
-		return errors.New("ошибка удаления колонки")
+	var count int64
+	if err := tx.Model(&models.Column{}).Where("id = ? AND board_id = ?", targetID, boardID).
+		Count(&count).Error; err != nil {
+		return errors.New("ошибка получения колонки")
+	}
+	if count == 0 {
+		return errors.New("колонка для переноса карточек не найдена")
 	}
 
-	if result.RowsAffected == 0 {
-		return errors.New("колонка не найдена")
+	// Мягкий лимит целевой колонки при переносе не мешает, жесткий — запрещает перенос
+	if _, err := checkWIPLimit(tx, targetID, len(cards)); err != nil {
+		return err
+	}
+
+	rank, err := rankAfterLast(rankedCards(tx, targetID))
+	if err != nil {
+		return errors.New("ошибка получения порядка карточек")
+	}
+
+	for i := range cards {
+		card := &cards[i]
+		before := cardSnapshot(card)
+
+		if i > 0 {
+			rank = lexorank.Between(rank, "")
+		}
+		if err := tx.Model(card).Updates(map[string]interface{}{
+			"column_id":  targetID,
+			"rank":       rank,
+			"version":    gorm.Expr("version + 1"),
+			"updated_by": actor.UserID,
+		}).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка переноса карточек")
+		}
+		card.ColumnID = targetID
+		card.Rank = rank
+
+		if err := recordActivity(tx, actor, events.CardMoved, boardID, card.ID, targetID,
+			diffSnapshots(before, cardSnapshot(card))); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.Card{}).Where("column_id = ? AND archived_at IS NOT NULL", columnID).
+		Update("column_id", targetID).Error; err != nil {
+		return errors.New("ошибка переноса архивных карточек")
 	}
 
 	return nil
 }
 
-// ValidatePassword проверяет пароль ��оски
+// ValidatePassword проверяет пароль доски для гостевого доступа
 func (s *BoardService) ValidatePassword(boardID, password string) error {
 	var board models.Board
 
-	if err := s.db.Select("password_hash").First(&board, "id = ?", boardID).Error; err != nil {
+	if err := s.db.Select("password_hash", "guest_access").First(&board, "id = ?", boardID).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("доска не найдена")
 		}
 		return errors.New("ошибка проверки пароля")
 	}
 
+	if !board.GuestAccess || board.PasswordHash == "" {
+		return errors.New("гостевой доступ к доске отключен")
+	}
+
 	// Сравниваем хеш пароля
 	if err := bcrypt.CompareHashAndPassword([]byte(board.PasswordHash), []byte(password)); err != nil {
 		return errors.New("неверный пароль")
@@ -170,21 +553,122 @@ func (s *BoardService) ValidatePassword(boardID, password string) error {
 	return nil
 }
 
-// CreateCard создает новую карточку в указанной колонке
-func (s *BoardService) CreateCard(boardID string, req models.CreateCardRequest) (*models.Card, error) {
-	// Проверяем, что доска существует
-	var board models.Board
-	if err := s.db.First(&board, "id = ?", boardID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("доска не найдена")
+// SetGuestAccess включает или отключает гостевой доступ к доске по паролю.
+// Пароль можно не передавать, если он уже был задан ранее.
+func (s *BoardService) SetGuestAccess(actor Actor, boardID string, req models.GuestAccessRequest) error {
+	// Гость не может быть владельцем доски
+	if req.Role != "" && (!req.Role.Valid() || req.Role == models.RoleOwner) {
+		return errors.New("недопустимая роль для гостевого доступа")
+	}
+
+	var passwordHash []byte
+	if req.Password != "" {
+		if len(req.Password) < 6 {
+			return errors.New("пароль должен содержать минимум 6 символов")
 		}
-		return nil, errors.New("ошибка получения доски")
+		var err error
+		passwordHash, err = bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return errors.New("ошибка хеширования пароля")
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var board models.Board
+		if err := tx.Select("id", "password_hash", "guest_access", "guest_role").First(&board, "id = ?", boardID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("доска не найдена")
+			}
+			return errors.New("ошибка получения доски")
+		}
+
+		if req.Enabled && board.PasswordHash == "" && passwordHash == nil {
+			return errors.New("для гостевого доступа нужно задать пароль")
+		}
+
+		updates := map[string]interface{}{
+			"guest_access": req.Enabled,
+		}
+		if req.Role != "" {
+			updates["guest_role"] = req.Role
+		}
+		if passwordHash != nil {
+			updates["password_hash"] = string(passwordHash)
+		}
+
+		// В журнал попадают только доступ и роль, хеш пароля не записываем
+		changes := models.FieldChanges{}
+		if board.GuestAccess != req.Enabled {
+			changes["guest_access"] = models.FieldChange{Before: board.GuestAccess, After: req.Enabled}
+		}
+		if req.Role != "" && board.GuestRole != req.Role {
+			changes["guest_role"] = models.FieldChange{Before: board.GuestRole, After: req.Role}
+		}
+		if passwordHash != nil {
+			changes["password"] = models.FieldChange{Before: nil, After: "changed"}
+		}
+
+		if err := tx.Model(&board).Updates(updates).Error; err != nil {
+			return errors.New("ошибка обновления доступа к доске")
+		}
+
+		return recordActivity(tx, actor, events.BoardAccessChanged, boardID, "", "", changes)
+	})
+}
+
+// DeleteBoard удаляет доску со всеми колонками, карточками и файлами вложений
+func (s *BoardService) DeleteBoard(actor Actor, boardID string) error {
+	var blobs []string
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var board models.Board
+		if err := tx.Select("id").First(&board, "id = ?", boardID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("доска не найдена")
+			}
+			return errors.New("ошибка получения доски")
+		}
+
+		var err error
+		blobs, err = attachmentKeys(tx, "board_id = ?", boardID)
+		if err != nil {
+			return err
+		}
+
+		// Остальные данные доски удаляются каскадом
+		if err := tx.Delete(&board).Error; err != nil {
+			return errors.New("ошибка удаления доски")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Получаем сле��ующий порядковый номер для колонки
-	var maxOrder int64
-	s.db.Model(&models.Card{}).Where("board_id = ? AND column_id = ?", boardID, req.ColumnID).
-		Select("COALESCE(MAX(order_num), 0)").Scan(&maxOrder)
+	removeBlobs(s.store, blobs)
+	s.publish(events.BoardDeleted, actor, boardID, deletedPayload{ID: boardID})
+
+	return nil
+}
+
+// CreateCard создает новую карточку в конце указанной колонки
+func (s *BoardService) CreateCard(actor Actor, boardID string, req models.CreateCardRequest) (*models.Card, error) {
+	if err := validateCardFields(req.Priority, req.Estimate); err != nil {
+		return nil, err
+	}
+	customFields, err := mergeCustomFields(s.db, boardID, nil, req.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем, что колонка существует и принадлежит доске
+	var column models.Column
+	if err := s.db.Select("id").Where("id = ? AND board_id = ?", req.ColumnID, boardID).First(&column).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("колонка не найдена")
+		}
+		return nil, errors.New("ошибка получения колонки")
+	}
 
 	card := &models.Card{
 		ID:          generateID(),
@@ -193,30 +677,68 @@ func (s *BoardService) CreateCard(boardID string, req models.CreateCardRequest)
 		Description: req.Description,
 		Assignee:    req.Assignee,
 		Deadline:    req.Deadline,
+		Priority:    req.Priority,
+		Estimate:    req.Estimate,
 		ColumnID:    req.ColumnID,
-		OrderNum:    int(maxOrder) + 1,
+		CreatedBy:   actor.UserID,
+		UpdatedBy:   actor.UserID,
+
+		CustomFields: customFields,
+	}
+
+	if req.SwimlaneID != "" {
+		var swimlane models.Swimlane
+		if err := loadSwimlane(s.db, boardID, req.SwimlaneID, &swimlane); err != nil {
+			return nil, err
+		}
+		card.SwimlaneID = &swimlane.ID
 	}
 
-	// Сохраняем карточку в БД
-	if err := s.db.Create(card).Error; err != nil {
-		return nil, errors.New("ошибка создания карточки")
+	err = withRankRetry(s.db, func(tx *gorm.DB) error {
+		overLimit, err := checkWIPLimit(tx, req.ColumnID, 1)
+		if err != nil {
+			return err
+		}
+		card.WIPLimitExceeded = overLimit
+
+		// Ранг после последней карточки колонки
+		rank, err := rankAfterLast(rankedCards(tx, req.ColumnID))
+		if err != nil {
+			return errors.New("ошибка получения порядка карточек")
+		}
+		card.Rank = rank
+
+		// Сохраняем карточку в БД
+		if err := tx.Create(card).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка создания карточки")
+		}
+
+		if err := setCardLabels(tx, boardID, card, req.LabelIDs); err != nil {
+			return err
+		}
+
+		changes := diffSnapshots(nil, cardSnapshot(card))
+		if overLimit {
+			changes["wip_limit_exceeded"] = models.FieldChange{After: true}
+		}
+		return recordActivity(tx, actor, events.CardCreated, boardID, card.ID, card.ColumnID, changes)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	s.publish(events.CardCreated, actor, boardID, card)
+
 	return card, nil
 }
 
 // UpdateCard обновляет карточку
-func (s *BoardService) UpdateCard(boardID, cardID string, req models.UpdateCardRequest) (*models.Card, error) {
+func (s *BoardService) UpdateCard(actor Actor, boardID, cardID string, req models.UpdateCardRequest) (*models.Card, error) {
 	var card models.Card
 
-	// Проверяем, что карточка существует и принадлежлежи�� доске
-	if err := s.db.Where("id = ? AND board_id = ?", cardID, boardID).First(&card).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("карточка не найдена")
-		}
-		return nil, errors.New("ошибка получения карточки")
-	}
-
 	// Подготавливаем данные для обновления
 	updates := make(map[string]interface{})
 
@@ -231,78 +753,219 @@ func (s *BoardService) UpdateCard(boardID, cardID string, req models.UpdateCardR
 	}
 	// Обновляем дедлайн (даже если nil, чтобы можно было очистить)
 	updates["deadline"] = req.Deadline
+	updates["updated_by"] = actor.UserID
 
-	// Если есть что обновлять
-	if len(updates) > 0 {
-		if err := s.db.Model(&card).Updates(updates).Error; err != nil {
-			return nil, errors.New("ошибка обновления карточки")
+	if req.Priority != nil {
+		if !req.Priority.Valid() {
+			return nil, errUnknownPriority
 		}
+		updates["priority"] = *req.Priority
+	}
+	if req.Estimate != nil {
+		if *req.Estimate < 0 {
+			return nil, errNegativeEstimate
+		}
+		updates["estimate"] = *req.Estimate
+	}
+	if req.ClearEstimate {
+		updates["estimate"] = nil
 	}
 
-	// Перезагружаем карточку с обновленными данными
-	if err := s.db.First(&card, "id = ?", cardID).Error; err != nil {
-		return nil, errors.New("ошибка перезагрузки карточки")
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Проверяем, что карточка существует и принадлежит доске
+		if err := tx.Preload("Labels").Where("id = ? AND board_id = ?", cardID, boardID).First(&card).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("карточка не найдена")
+			}
+			return errors.New("ошибка получения карточки")
+		}
+		before := cardSnapshot(&card)
+
+		if req.CustomFields != nil {
+			customFields, err := mergeCustomFields(tx, boardID, card.CustomFields, req.CustomFields)
+			if err != nil {
+				return err
+			}
+			updates["custom_fields"] = customFields
+		}
+
+		// Обновляем только ту версию, которую видел клиент
+		err := updateVersioned(tx, &card, req.Version, updates)
+		if errors.Is(err, errVersionMismatch) {
+			return err
+		}
+		if err != nil {
+			return errors.New("ошибка обновления карточки")
+		}
+
+		if req.LabelIDs != nil {
+			if err := setCardLabels(tx, boardID, &card, *req.LabelIDs); err != nil {
+				return err
+			}
+		}
+
+		// Перезагружаем карточку с обновленными данными
+		if err := tx.Preload("Labels").First(&card, "id = ?", cardID).Error; err != nil {
+			return errors.New("ошибка перезагрузки карточки")
+		}
+
+		return recordActivity(tx, actor, events.CardUpdated, boardID, cardID, card.ColumnID,
+			diffSnapshots(before, cardSnapshot(&card)))
+	})
+	if errors.Is(err, errVersionMismatch) {
+		return nil, s.cardConflict(cardID)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CardUpdated, actor, boardID, card)
 
 	return &card, nil
 }
 
-// MoveCard перемещает карточку между колонками
-func (s *BoardService) MoveCard(boardID, cardID string, req models.MoveCardRequest) (*models.Card, error) {
+// MoveCard перемещает карточку на указанную позицию в колонке (в той же или другой).
+// Позиция Order начинается с 1; 0 или позиция за концом колонки означает перемещение в конец.
+// Меняется только сама карточка: её новый ранг вычисляется между соседями.
+func (s *BoardService) MoveCard(actor Actor, boardID, cardID string, req models.MoveCardRequest) (*models.Card, error) {
 	var card models.Card
 
-	// Начинаем транзакцию
-	return &card, s.db.Transaction(func(tx *gorm.DB) error {
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
 		// Получаем текущую карточку
-		if err := tx.Where("id = ? AND board_id = ?", cardID, boardID).First(&card).Error; err != nil {
+		if err := tx.Preload("Labels").Where("id = ? AND board_id = ?", cardID, boardID).First(&card).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return errors.New("карточка не найдена")
 			}
 			return errors.New("ошибка получения карточки")
 		}
+		before := cardSnapshot(&card)
 
-		// Если колонка не изменилась, просто возвращаем карточку
-		if card.ColumnID == req.ColumnID {
-			return nil
+		// Проверяем, что целевая колонка принадлежит доске
+		var count int64
+		if err := tx.Model(&models.Column{}).Where("id = ? AND board_id = ?", req.ColumnID, boardID).
+			Count(&count).Error; err != nil {
+			return errors.New("ошибка получения колонки")
+		}
+		if count == 0 {
+			return errors.New("колонка не найдена")
 		}
 
-		// Получаем следующий порядковый номер для целевой колонки
-		var maxOrder int64
-		tx.Model(&models.Card{}).Where("board_id = ? AND column_id = ?", boardID, req.ColumnID).
-			Select("COALESCE(MAX(order_num), 0)").Scan(&maxOrder)
+		// Перемещение внутри колонки и перемещение архивной карточки лимит не меняют
+		adding := 0
+		if card.ColumnID != req.ColumnID && card.ArchivedAt == nil {
+			adding = 1
+		}
+		overLimit, err := checkWIPLimit(tx, req.ColumnID, adding)
+		if err != nil {
+			return err
+		}
 
-		// Обновляем карточку
+		// Ранг между соседями новой позиции среди остальных карточек колонки,
+		// а при смене дорожки — среди карточек целевой дорожки в колонке
 		updates := map[string]interface{}{
-			"column_id": req.ColumnID,
-			"order_num": int(maxOrder) + 1,
+			"column_id":  req.ColumnID,
+			"updated_by": actor.UserID,
+		}
+		var rank string
+		if req.SwimlaneID == nil {
+			rank, err = rankForPosition(rankedCards(tx, req.ColumnID).
+				Where("id <> ?", cardID), req.Order)
+		} else {
+			lane := rankedCards(tx, req.ColumnID).Where("id <> ?", cardID)
+			if *req.SwimlaneID == "" {
+				updates["swimlane_id"] = nil
+				lane = lane.Where("swimlane_id IS NULL")
+			} else {
+				var swimlane models.Swimlane
+				if err := loadSwimlane(tx, boardID, *req.SwimlaneID, &swimlane); err != nil {
+					return err
+				}
+				updates["swimlane_id"] = swimlane.ID
+				lane = lane.Where("swimlane_id = ?", swimlane.ID)
+			}
+			rank, err = rankForGroupPosition(rankedCards(tx, req.ColumnID).
+				Where("id <> ?", cardID), lane, req.Order)
+		}
+		if err != nil {
+			return errors.New("ошибка получения порядка карточек")
 		}
+		updates["rank"] = rank
 
-		if err := tx.Model(&card).Updates(updates).Error; err != nil {
+		err = updateVersioned(tx, &card, req.Version, updates)
+		if errors.Is(err, gorm.ErrDuplicatedKey) || errors.Is(err, errVersionMismatch) {
+			return err
+		}
+		if err != nil {
 			return errors.New("ошибка перемещения карточки")
 		}
 
 		// Перезагружаем карточку
-		if err := tx.First(&card, "id = ?", cardID).Error; err != nil {
+		if err := tx.Preload("Labels").First(&card, "id = ?", cardID).Error; err != nil {
 			return errors.New("ошибка перезагрузки карточки")
 		}
+		card.WIPLimitExceeded = overLimit
 
-		return nil
+		changes := diffSnapshots(before, cardSnapshot(&card))
+		if overLimit {
+			changes["wip_limit_exceeded"] = models.FieldChange{After: true}
+		}
+		return recordActivity(tx, actor, events.CardMoved, boardID, cardID, card.ColumnID, changes)
 	})
+	if errors.Is(err, errVersionMismatch) {
+		return nil, s.cardConflict(cardID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CardMoved, actor, boardID, card)
+
+	return &card, nil
 }
 
-// DeleteCard удаляет карточку
-func (s *BoardService) DeleteCard(boardID, cardID string) error {
-	result := s.db.Where("id = ? AND board_id = ?", cardID, boardID).Delete(&models.Card{})
+// DeleteCard перемещает карточку в корзину
+func (s *BoardService) DeleteCard(actor Actor, boardID, cardID string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var card models.Card
+		if err := tx.Preload("Labels").Where("id = ? AND board_id = ?", cardID, boardID).First(&card).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("карточка не найдена")
+			}
+			return errors.New("ошибка получения карточки")
+		}
+
+		if err := tx.Delete(&card).Error; err != nil {
+			return errors.New("ошибка удаления карточки")
+		}
 
-	if result.Error != nil {
-		return errors.New("ошибка удаления карточки")
+		return recordActivity(tx, actor, events.CardDeleted, boardID, cardID, card.ColumnID,
+			diffSnapshots(cardSnapshot(&card), nil))
+	})
+	if err != nil {
+		return err
 	}
 
-	if result.RowsAffected == 0 {
+	s.publish(events.CardDeleted, actor, boardID, deletedPayload{ID: cardID})
+
+	return nil
+}
+
+// cardConflict возвращает ошибку конфликта с актуальным состоянием карточки
+func (s *BoardService) cardConflict(cardID string) error {
+	var current models.Card
+	if err := s.db.Preload("Labels").First(&current, "id = ?", cardID).Error; err != nil {
 		return errors.New("карточка не найдена")
 	}
+	return &ConflictError{Current: current}
+}
 
-	return nil
+// columnConflict возвращает ошибку конфликта с актуальным состоянием колонки
+func (s *BoardService) columnConflict(columnID string) error {
+	var current models.Column
+	if err := s.db.First(&current, "id = ?", columnID).Error; err != nil {
+		return errors.New("колонка не найдена")
+	}
+	return &ConflictError{Current: current}
 }
 
 func generateID() string {
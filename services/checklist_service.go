@@ -0,0 +1,450 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"task-board/database"
+	"task-board/events"
+	"task-board/models"
+)
+
+type ChecklistService struct {
+	publisher
+	db *gorm.DB
+}
+
+func NewChecklistService(broker events.Broker) *ChecklistService {
+	return &ChecklistService{
+		publisher: publisher{broker: broker},
+		db:        database.DB,
+	}
+}
+
+// List возвращает чек-листы карточки с пунктами в порядке рангов
+func (s *ChecklistService) List(boardID, cardID string) ([]models.Checklist, error) {
+	if _, err := loadBoardCard(s.db, boardID, cardID); err != nil {
+		return nil, err
+	}
+
+	checklists := []models.Checklist{}
+	if err := s.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("rank ASC")
+	}).Where("card_id = ?", cardID).Order("rank ASC").Find(&checklists).Error; err != nil {
+		return nil, errors.New("ошибка получения чек-листов")
+	}
+
+	return checklists, nil
+}
+
+// CreateChecklist добавляет чек-лист в конец карточки
+func (s *ChecklistService) CreateChecklist(actor Actor, boardID, cardID string, req models.ChecklistRequest) (*models.Checklist, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, errors.New("название чек-листа обязательно")
+	}
+
+	checklist := &models.Checklist{
+		ID:      generateID(),
+		BoardID: boardID,
+		CardID:  cardID,
+		Title:   title,
+		Items:   []models.ChecklistItem{},
+	}
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		if _, err := loadBoardCard(tx, boardID, cardID); err != nil {
+			return err
+		}
+
+		rank, err := rankAfterLast(tx.Model(&models.Checklist{}).Where("card_id = ?", cardID))
+		if err != nil {
+			return errors.New("ошибка получения порядка чек-листов")
+		}
+		checklist.Rank = rank
+
+		if err := tx.Create(checklist).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка создания чек-листа")
+		}
+
+		changes := models.FieldChanges{"checklist_id": {After: checklist.ID}, "title": {After: title}}
+		return recordActivity(tx, actor, events.ChecklistCreated, boardID, cardID, "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ChecklistCreated, actor, boardID, checklist)
+
+	return checklist, nil
+}
+
+// UpdateChecklist переименовывает чек-лист
+func (s *ChecklistService) UpdateChecklist(actor Actor, boardID, cardID, checklistID string, req models.ChecklistRequest) (*models.Checklist, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		return nil, errors.New("название чек-листа обязательно")
+	}
+
+	var checklist models.Checklist
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadChecklist(tx, boardID, cardID, checklistID, &checklist); err != nil {
+			return err
+		}
+
+		changes := models.FieldChanges{
+			"checklist_id": {After: checklistID},
+			"title":        {Before: checklist.Title, After: title},
+		}
+
+		if err := tx.Model(&checklist).Update("title", title).Error; err != nil {
+			return errors.New("ошибка обновления чек-листа")
+		}
+
+		return recordActivity(tx, actor, events.ChecklistUpdated, boardID, cardID, "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ChecklistUpdated, actor, boardID, checklist)
+
+	return &checklist, nil
+}
+
+// DeleteChecklist удаляет чек-лист со всеми пунктами
+func (s *ChecklistService) DeleteChecklist(actor Actor, boardID, cardID, checklistID string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var checklist models.Checklist
+		if err := loadChecklist(tx, boardID, cardID, checklistID, &checklist); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&checklist).Error; err != nil {
+			return errors.New("ошибка удаления чек-листа")
+		}
+
+		changes := models.FieldChanges{"checklist_id": {Before: checklistID}, "title": {Before: checklist.Title}}
+		return recordActivity(tx, actor, events.ChecklistDeleted, boardID, cardID, "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.ChecklistDeleted, actor, boardID, deletedPayload{ID: checklistID})
+
+	return nil
+}
+
+// CreateItem добавляет пункт в конец чек-листа
+func (s *ChecklistService) CreateItem(actor Actor, boardID, cardID, checklistID string, req models.CreateChecklistItemRequest) (*models.ChecklistItem, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return nil, errors.New("текст пункта обязателен")
+	}
+
+	item := &models.ChecklistItem{
+		ID:          generateID(),
+		ChecklistID: checklistID,
+		Text:        text,
+		Assignee:    req.Assignee,
+		DueDate:     req.DueDate,
+	}
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		var checklist models.Checklist
+		if err := loadChecklist(tx, boardID, cardID, checklistID, &checklist); err != nil {
+			return err
+		}
+
+		rank, err := rankAfterLast(tx.Model(&models.ChecklistItem{}).Where("checklist_id = ?", checklistID))
+		if err != nil {
+			return errors.New("ошибка получения порядка пунктов")
+		}
+		item.Rank = rank
+
+		if err := tx.Create(item).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка создания пункта")
+		}
+
+		return recordActivity(tx, actor, events.ChecklistItemCreated, boardID, cardID, "",
+			diffSnapshots(nil, itemSnapshot(item)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ChecklistItemCreated, actor, boardID, item)
+
+	return item, nil
+}
+
+// UpdateItem меняет текст, отметку о выполнении, ответственного или срок пункта
+func (s *ChecklistService) UpdateItem(actor Actor, boardID, cardID, checklistID, itemID string, req models.UpdateChecklistItemRequest) (*models.ChecklistItem, error) {
+	updates := make(map[string]interface{})
+
+	if req.Text != nil {
+		text := strings.TrimSpace(*req.Text)
+		if text == "" {
+			return nil, errors.New("текст пункта обязателен")
+		}
+		updates["text"] = text
+	}
+	if req.Done != nil {
+		updates["done"] = *req.Done
+	}
+	if req.Assignee != nil {
+		updates["assignee"] = *req.Assignee
+	}
+	if req.DueDate != nil {
+		updates["due_date"] = req.DueDate
+	} else if req.ClearDueDate {
+		updates["due_date"] = nil
+	}
+
+	var item models.ChecklistItem
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadChecklistItem(tx, boardID, cardID, checklistID, itemID, &item); err != nil {
+			return err
+		}
+		before := itemSnapshot(&item)
+
+		if len(updates) > 0 {
+			if err := tx.Model(&item).Updates(updates).Error; err != nil {
+				return errors.New("ошибка обновления пункта")
+			}
+		}
+
+		if err := tx.First(&item, "id = ?", itemID).Error; err != nil {
+			return errors.New("ошибка перезагрузки пункта")
+		}
+
+		return recordActivity(tx, actor, events.ChecklistItemUpdated, boardID, cardID, "",
+			diffSnapshots(before, itemSnapshot(&item)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ChecklistItemUpdated, actor, boardID, item)
+
+	return &item, nil
+}
+
+// MoveItem перемещает пункт на позицию в том же или другом чек-листе карточки
+func (s *ChecklistService) MoveItem(actor Actor, boardID, cardID, checklistID, itemID string, req models.MoveChecklistItemRequest) (*models.ChecklistItem, error) {
+	targetID := req.ChecklistID
+	if targetID == "" {
+		targetID = checklistID
+	}
+
+	var item models.ChecklistItem
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		if err := loadChecklistItem(tx, boardID, cardID, checklistID, itemID, &item); err != nil {
+			return err
+		}
+		before := itemSnapshot(&item)
+
+		// Пункты можно переносить только между чек-листами одной карточки
+		var target models.Checklist
+		if err := loadChecklist(tx, boardID, cardID, targetID, &target); err != nil {
+			return err
+		}
+
+		rank, err := rankForPosition(tx.Model(&models.ChecklistItem{}).
+			Where("checklist_id = ? AND id <> ?", targetID, itemID), req.Order)
+		if err != nil {
+			return errors.New("ошибка получения порядка пунктов")
+		}
+
+		if err := tx.Model(&item).Updates(map[string]interface{}{
+			"checklist_id": targetID,
+			"rank":         rank,
+		}).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка перемещения пункта")
+		}
+
+		if err := tx.First(&item, "id = ?", itemID).Error; err != nil {
+			return errors.New("ошибка перезагрузки пункта")
+		}
+
+		return recordActivity(tx, actor, events.ChecklistItemMoved, boardID, cardID, "",
+			diffSnapshots(before, itemSnapshot(&item)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ChecklistItemMoved, actor, boardID, item)
+
+	return &item, nil
+}
+
+// DeleteItem удаляет пункт чек-листа
+func (s *ChecklistService) DeleteItem(actor Actor, boardID, cardID, checklistID, itemID string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var item models.ChecklistItem
+		if err := loadChecklistItem(tx, boardID, cardID, checklistID, itemID, &item); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&item).Error; err != nil {
+			return errors.New("ошибка удаления пункта")
+		}
+
+		return recordActivity(tx, actor, events.ChecklistItemDeleted, boardID, cardID, "",
+			diffSnapshots(itemSnapshot(&item), nil))
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.ChecklistItemDeleted, actor, boardID, deletedPayload{ID: itemID})
+
+	return nil
+}
+
+// ConvertItem превращает пункт в отдельную карточку в конце колонки исходной карточки.
+// Текст пункта становится заголовком, срок — дедлайном; сам пункт удаляется.
+func (s *ChecklistService) ConvertItem(actor Actor, boardID, cardID, checklistID, itemID string) (*models.Card, error) {
+	var card *models.Card
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		source, err := loadBoardCard(tx, boardID, cardID)
+		if err != nil {
+			return err
+		}
+
+		var item models.ChecklistItem
+		if err := loadChecklistItem(tx, boardID, cardID, checklistID, itemID, &item); err != nil {
+			return err
+		}
+
+		overLimit, err := checkWIPLimit(tx, source.ColumnID, 1)
+		if err != nil {
+			return err
+		}
+
+		rank, err := rankAfterLast(rankedCards(tx, source.ColumnID))
+		if err != nil {
+			return errors.New("ошибка получения порядка карточек")
+		}
+
+		card = &models.Card{
+			ID:        generateID(),
+			BoardID:   boardID,
+			Title:     item.Text,
+			Assignee:  item.Assignee,
+			Deadline:  item.DueDate,
+			ColumnID:  source.ColumnID,
+			Rank:      rank,
+			CreatedBy: actor.UserID,
+			UpdatedBy: actor.UserID,
+			Labels:    []models.Label{},
+
+			WIPLimitExceeded: overLimit,
+		}
+
+		if err := tx.Create(card).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка создания карточки")
+		}
+
+		if err := tx.Delete(&item).Error; err != nil {
+			return errors.New("ошибка удаления пункта")
+		}
+
+		if err := recordActivity(tx, actor, events.ChecklistItemDeleted, boardID, cardID, "",
+			diffSnapshots(itemSnapshot(&item), nil)); err != nil {
+			return err
+		}
+
+		changes := diffSnapshots(nil, cardSnapshot(card))
+		changes["converted_from"] = models.FieldChange{After: cardID}
+		if overLimit {
+			changes["wip_limit_exceeded"] = models.FieldChange{After: true}
+		}
+		return recordActivity(tx, actor, events.CardCreated, boardID, card.ID, card.ColumnID, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ChecklistItemDeleted, actor, boardID, deletedPayload{ID: itemID})
+	s.publish(events.CardCreated, actor, boardID, card)
+
+	return card, nil
+}
+
+// itemSnapshot возвращает отслеживаемые поля пункта чек-листа
+func itemSnapshot(item *models.ChecklistItem) snapshot {
+	var dueDate interface{}
+	if item.DueDate != nil {
+		dueDate = item.DueDate.UTC().Format(time.RFC3339)
+	}
+
+	return snapshot{
+		"item_id":      item.ID,
+		"checklist_id": item.ChecklistID,
+		"text":         item.Text,
+		"done":         item.Done,
+		"assignee":     item.Assignee,
+		"due_date":     dueDate,
+		"rank":         item.Rank,
+	}
+}
+
+// loadBoardCard загружает карточку текущей доски
+func loadBoardCard(tx *gorm.DB, boardID, cardID string) (*models.Card, error) {
+	var card models.Card
+	if err := tx.First(&card, "id = ? AND board_id = ?", cardID, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("карточка не найдена")
+		}
+		return nil, errors.New("ошибка получения карточки")
+	}
+	return &card, nil
+}
+
+// loadChecklist загружает чек-лист карточки текущей доски
+func loadChecklist(tx *gorm.DB, boardID, cardID, checklistID string, checklist *models.Checklist) error {
+	if err := tx.First(checklist, "id = ? AND card_id = ? AND board_id = ?", checklistID, cardID, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("чек-лист не найден")
+		}
+		return errors.New("ошибка получения чек-листа")
+	}
+	return nil
+}
+
+// loadChecklistItem загружает пункт чек-листа карточки текущей доски
+func loadChecklistItem(tx *gorm.DB, boardID, cardID, checklistID, itemID string, item *models.ChecklistItem) error {
+	var checklist models.Checklist
+	if err := loadChecklist(tx, boardID, cardID, checklistID, &checklist); err != nil {
+		return err
+	}
+
+	if err := tx.First(item, "id = ? AND checklist_id = ?", itemID, checklistID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("пункт чек-листа не найден")
+		}
+		return errors.New("ошибка получения пункта чек-листа")
+	}
+	return nil
+}
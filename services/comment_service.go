@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"task-board/database"
+	"task-board/events"
+	"task-board/models"
+)
+
+// ErrCommentForbidden возвращается при попытке изменить чужой комментарий
+var ErrCommentForbidden = errors.New("можно изменять только свои комментарии")
+
+type CommentService struct {
+	publisher
+	db *gorm.DB
+}
+
+func NewCommentService(broker events.Broker) *CommentService {
+	return &CommentService{
+		publisher: publisher{broker: broker},
+		db:        database.DB,
+	}
+}
+
+// List возвращает комментарии карточки в виде веток: комментарии верхнего уровня
+// с ответами, от старых к новым. Удаленный комментарий остается в ветке без текста,
+// только если на него есть ответы.
+func (s *CommentService) List(boardID, cardID string) ([]models.Comment, error) {
+	if err := s.checkCard(s.db, boardID, cardID); err != nil {
+		return nil, err
+	}
+
+	var all []models.Comment
+	if err := s.db.Unscoped().Where("card_id = ?", cardID).
+		Order("created_at ASC, id ASC").Find(&all).Error; err != nil {
+		return nil, errors.New("ошибка получения комментариев")
+	}
+
+	replies := make(map[string][]models.Comment)
+	for _, comment := range all {
+		if comment.ParentID != nil && !comment.DeletedAt.Valid {
+			replies[*comment.ParentID] = append(replies[*comment.ParentID], comment)
+		}
+	}
+
+	threads := []models.Comment{}
+	for _, comment := range all {
+		if comment.ParentID != nil {
+			continue
+		}
+		comment.Replies = replies[comment.ID]
+		if comment.DeletedAt.Valid {
+			if len(comment.Replies) == 0 {
+				continue
+			}
+			comment.Body = ""
+		}
+		threads = append(threads, comment)
+	}
+
+	return threads, nil
+}
+
+// Create добавляет комментарий к карточке или ответ на комментарий верхнего уровня
+func (s *CommentService) Create(actor Actor, boardID, cardID string, req models.CreateCommentRequest) (*models.Comment, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, errors.New("текст комментария обязателен")
+	}
+
+	comment := &models.Comment{
+		ID:       generateID(),
+		BoardID:  boardID,
+		CardID:   cardID,
+		AuthorID: actor.UserID,
+		Body:     body,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.checkCard(tx, boardID, cardID); err != nil {
+			return err
+		}
+
+		if req.ParentID != "" {
+			var parent models.Comment
+			if err := tx.First(&parent, "id = ? AND card_id = ?", req.ParentID, cardID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("комментарий для ответа не найден")
+				}
+				return errors.New("ошибка получения комментария")
+			}
+			if parent.ParentID != nil {
+				return errors.New("ответить можно только на комментарий верхнего уровня")
+			}
+			comment.ParentID = &parent.ID
+		}
+
+		if err := tx.Create(comment).Error; err != nil {
+			return errors.New("ошибка создания комментария")
+		}
+		if err := refreshCommentText(tx, cardID); err != nil {
+			return err
+		}
+
+		changes := models.FieldChanges{"comment_id": {After: comment.ID}, "body": {After: body}}
+		return recordActivity(tx, actor, events.CommentCreated, boardID, cardID, "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CommentCreated, actor, boardID, comment)
+
+	return comment, nil
+}
+
+// Update меняет текст своего комментария, сохраняя прежний текст в истории правок
+func (s *CommentService) Update(actor Actor, boardID, cardID, commentID string, req models.UpdateCommentRequest) (*models.Comment, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, errors.New("текст комментария обязателен")
+	}
+
+	var comment models.Comment
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := s.loadComment(tx, boardID, cardID, commentID, &comment); err != nil {
+			return err
+		}
+
+		// Гости не могут редактировать комментарии: у них нет учетной записи
+		if actor.IsGuest() || comment.AuthorID != actor.UserID {
+			return ErrCommentForbidden
+		}
+
+		if comment.Body == body {
+			return nil
+		}
+
+		revision := &models.CommentRevision{
+			ID:        generateID(),
+			CommentID: comment.ID,
+			Body:      comment.Body,
+			EditedBy:  actor.UserID,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return errors.New("ошибка сохранения истории комментария")
+		}
+
+		changes := models.FieldChanges{"comment_id": {After: comment.ID}, "body": {Before: comment.Body, After: body}}
+
+		now := time.Now()
+		if err := tx.Model(&comment).Updates(map[string]interface{}{
+			"body":      body,
+			"edited_at": now,
+		}).Error; err != nil {
+			return errors.New("ошибка обновления комментария")
+		}
+		comment.Body = body
+		comment.EditedAt = &now
+
+		if err := refreshCommentText(tx, cardID); err != nil {
+			return err
+		}
+
+		return recordActivity(tx, actor, events.CommentUpdated, boardID, cardID, "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CommentUpdated, actor, boardID, comment)
+
+	return &comment, nil
+}
+
+// Delete мягко удаляет комментарий. Удалить можно свой комментарий,
+// владелец доски может удалить любой.
+func (s *CommentService) Delete(actor Actor, boardID, cardID, commentID string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var comment models.Comment
+		if err := s.loadComment(tx, boardID, cardID, commentID, &comment); err != nil {
+			return err
+		}
+
+		isAuthor := !actor.IsGuest() && comment.AuthorID == actor.UserID
+		if !isAuthor && actor.Role != models.RoleOwner {
+			return ErrCommentForbidden
+		}
+
+		if err := tx.Delete(&comment).Error; err != nil {
+			return errors.New("ошибка удаления комментария")
+		}
+		if err := refreshCommentText(tx, cardID); err != nil {
+			return err
+		}
+
+		changes := models.FieldChanges{"comment_id": {Before: comment.ID}, "body": {Before: comment.Body}}
+		return recordActivity(tx, actor, events.CommentDeleted, boardID, cardID, "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.CommentDeleted, actor, boardID, deletedPayload{ID: commentID})
+
+	return nil
+}
+
+// History возвращает прежние версии текста комментария от старых к новым
+func (s *CommentService) History(boardID, cardID, commentID string) ([]models.CommentRevision, error) {
+	var comment models.Comment
+	if err := s.loadComment(s.db, boardID, cardID, commentID, &comment); err != nil {
+		return nil, err
+	}
+
+	revisions := []models.CommentRevision{}
+	if err := s.db.Where("comment_id = ?", commentID).
+		Order("created_at ASC").Find(&revisions).Error; err != nil {
+		return nil, errors.New("ошибка получения истории комментария")
+	}
+
+	return revisions, nil
+}
+
+// checkCard проверяет, что карточка существует и принадлежит доске
+func (s *CommentService) checkCard(tx *gorm.DB, boardID, cardID string) error {
+	var count int64
+	if err := tx.Model(&models.Card{}).Where("id = ? AND board_id = ?", cardID, boardID).
+		Count(&count).Error; err != nil {
+		return errors.New("ошибка получения карточки")
+	}
+	if count == 0 {
+		return errors.New("карточка не найдена")
+	}
+	return nil
+}
+
+// loadComment загружает неудаленный комментарий карточки текущей доски
+func (s *CommentService) loadComment(tx *gorm.DB, boardID, cardID, commentID string, comment *models.Comment) error {
+	if err := tx.First(comment, "id = ? AND card_id = ? AND board_id = ?", commentID, cardID, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("комментарий не найден")
+		}
+		return errors.New("ошибка получения комментария")
+	}
+	return nil
+}
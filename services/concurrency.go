@@ -0,0 +1,43 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ConflictError возвращается, когда объект изменили после того, как клиент его получил.
+// Current содержит актуальное состояние объекта для показа клиенту.
+type ConflictError struct {
+	Current interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return "объект был изменен другим пользователем"
+}
+
+// errVersionMismatch сигнализирует о конфликте версий внутри транзакции
+var errVersionMismatch = errors.New("версия не совпадает")
+
+// updateVersioned применяет updates к строке model и увеличивает её версию.
+// Если expected задан, строка обновляется только при совпадении версии;
+// иначе возвращается errVersionMismatch.
+func updateVersioned(tx *gorm.DB, model interface{}, expected *int, updates map[string]interface{}) error {
+	updates["version"] = gorm.Expr("version + 1")
+
+	// Связи (например, метки карточки) сохраняются отдельно
+	query := tx.Model(model).Omit(clause.Associations)
+	if expected != nil {
+		query = query.Where("version = ?", *expected)
+	}
+
+	result := query.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"task-board/models"
+)
+
+// csvFields — столбцы CSV карточек в порядке экспорта. order — позиция карточки
+// в колонке начиная с 1; created и updated при импорте не читаются.
+var csvFields = []string{"id", "column", "title", "description", "assignee", "deadline", "order", "created", "updated"}
+
+// csvImportFields — поля карточки, которые читаются при импорте CSV
+var csvImportFields = map[string]bool{
+	"id":          true,
+	"column":      true,
+	"title":       true,
+	"description": true,
+	"assignee":    true,
+	"deadline":    true,
+	"order":       true,
+}
+
+// csvDeadlineLayouts — форматы дедлайна, которые понимает импорт CSV
+var csvDeadlineLayouts = []string{time.RFC3339, "2006-01-02 15:04", customFieldDateLayout}
+
+// csvBOM помечает файл как UTF-8 для табличных редакторов
+const csvBOM = "\ufeff"
+
+// ExportCardsCSV пишет в w карточки доски в формате CSV: по колонкам, внутри колонки —
+// по порядку. Архивные карточки и карточки в корзине не выгружаются.
+func (s *BoardService) ExportCardsCSV(boardID string, w io.Writer) error {
+	var columns []models.Column
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return tx.Preload("Cards", func(db *gorm.DB) *gorm.DB {
+			return db.Where("archived_at IS NULL").Order("rank ASC")
+		}).Where("board_id = ?", boardID).Order("rank ASC").Find(&columns).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return errors.New("ошибка получения карточек")
+	}
+
+	if _, err := io.WriteString(w, csvBOM); err != nil {
+		return err
+	}
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvFields); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		for i, card := range column.Cards {
+			deadline := ""
+			if card.Deadline != nil {
+				deadline = card.Deadline.Format(time.RFC3339)
+			}
+			record := []string{
+				card.ID,
+				escapeCSVCell(column.Name),
+				escapeCSVCell(card.Title),
+				escapeCSVCell(card.Description),
+				escapeCSVCell(card.Assignee),
+				deadline,
+				strconv.Itoa(i + 1),
+				card.CreatedAt.Format(time.RFC3339),
+				card.UpdatedAt.Format(time.RFC3339),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportCardsCSV создает и обновляет карточки доски по строкам CSV. Строка с id обновляет
+// существующую карточку, без id — создает новую в конце колонки. Колонки ищутся по названию
+// без учета регистра и создаются, если их нет. Каждая строка применяется отдельно через
+// обычные операции с карточками: ошибка строки попадает в отчет и не мешает остальным.
+// Пустые ячейки заголовка, описания и ответственного не очищают значения карточки,
+// а пустой дедлайн — очищает.
+func (s *BoardService) ImportCardsCSV(actor Actor, boardID string, data []byte, mapping []string) (*models.CSVImportResult, error) {
+	data = bytes.TrimPrefix(data, []byte(csvBOM))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = csvDelimiter(data)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &ValidationError{Message: "CSV-файл пуст"}
+	}
+	if err != nil {
+		return nil, &ValidationError{Message: "неверный заголовок CSV: " + err.Error()}
+	}
+	positions, err := csvFieldPositions(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := positions["title"]; !ok {
+		if _, ok := positions["id"]; !ok {
+			return nil, &ValidationError{Message: "в CSV нет столбца title или id"}
+		}
+	}
+
+	columns, err := newCSVColumns(s.db, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CSVImportResult{
+		ColumnsCreated: []string{},
+		Errors:         []models.CSVRowError{},
+		Warnings:       []models.CSVRowError{},
+	}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			result.Errors = append(result.Errors, models.CSVRowError{Row: parseErr.StartLine, Error: "неверная строка CSV: " + parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, &ValidationError{Message: "ошибка чтения CSV: " + err.Error()}
+		}
+		row, _ := reader.FieldPos(0)
+
+		values := make(map[string]string, len(positions))
+		blank := true
+		for field, i := range positions {
+			if i < len(record) {
+				values[field] = unescapeCSVCell(strings.TrimSpace(record[i]))
+				blank = blank && values[field] == ""
+			} else {
+				values[field] = ""
+			}
+		}
+		if blank {
+			continue
+		}
+
+		created, err := s.importCSVRow(actor, boardID, row, values, columns, result)
+		if err != nil {
+			result.Errors = append(result.Errors, models.CSVRowError{Row: row, Error: err.Error()})
+			continue
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+	}
+
+	return result, nil
+}
+
+// importCSVRow применяет одну строку CSV. Возвращает true, если карточка создана.
+// Если карточка создана или изменена, но не перемещена, строка считается
+// примененной, а ошибка перемещения добавляется в предупреждения.
+func (s *BoardService) importCSVRow(actor Actor, boardID string, row int, values map[string]string, columns *csvColumns, result *models.CSVImportResult) (bool, error) {
+	var deadline *time.Time
+	if value := values["deadline"]; value != "" {
+		parsed, err := parseCSVDeadline(value)
+		if err != nil {
+			return false, err
+		}
+		deadline = parsed
+	}
+
+	order := 0
+	if value := values["order"]; value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return false, errors.New("порядок карточки должен быть целым числом от 1")
+		}
+		order = parsed
+	}
+
+	var column *models.Column
+	if name := values["column"]; name != "" {
+		var err error
+		column, err = s.csvColumn(actor, boardID, name, columns, result)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if values["id"] == "" {
+		if values["title"] == "" {
+			return false, errors.New("заголовок карточки обязателен")
+		}
+		if column == nil {
+			return false, errors.New("не указана колонка карточки")
+		}
+
+		card, err := s.CreateCard(actor, boardID, models.CreateCardRequest{
+			Title:       values["title"],
+			Description: values["description"],
+			Assignee:    values["assignee"],
+			Deadline:    deadline,
+			ColumnID:    column.ID,
+		})
+		if err != nil {
+			return false, err
+		}
+		if order > 0 {
+			if _, err := s.MoveCard(actor, boardID, card.ID, models.MoveCardRequest{ColumnID: column.ID, Order: order}); err != nil {
+				result.Warnings = append(result.Warnings, models.CSVRowError{
+					Row:   row,
+					Error: "карточка создана, но не перемещена: " + err.Error(),
+				})
+			}
+		}
+		return true, nil
+	}
+
+	var card models.Card
+	if err := s.db.Where("id = ? AND board_id = ?", values["id"], boardID).First(&card).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, errors.New("карточка не найдена")
+		}
+		return false, errors.New("ошибка получения карточки")
+	}
+
+	// Дедлайн в запросе изменения заменяется всегда, поэтому без столбца deadline
+	// передается текущий
+	req := models.UpdateCardRequest{
+		Title:       values["title"],
+		Description: values["description"],
+		Assignee:    values["assignee"],
+		Deadline:    card.Deadline,
+	}
+	if _, ok := values["deadline"]; ok {
+		req.Deadline = deadline
+	}
+
+	changed := (req.Title != "" && req.Title != card.Title) ||
+		(req.Description != "" && req.Description != card.Description) ||
+		(req.Assignee != "" && req.Assignee != card.Assignee) ||
+		!sameDeadline(req.Deadline, card.Deadline)
+	if changed {
+		if _, err := s.UpdateCard(actor, boardID, card.ID, req); err != nil {
+			return false, err
+		}
+	}
+
+	move := models.MoveCardRequest{ColumnID: card.ColumnID, Order: order}
+	if column != nil {
+		move.ColumnID = column.ID
+	}
+	if move.ColumnID == card.ColumnID && order > 0 && card.ArchivedAt == nil {
+		var before int64
+		if err := rankedCards(s.db, card.ColumnID).Where("rank < ?", card.Rank).Count(&before).Error; err != nil {
+			return false, errors.New("ошибка получения порядка карточек")
+		}
+		if int(before)+1 == order {
+			move.Order = 0
+		}
+	}
+	if move.ColumnID != card.ColumnID || move.Order > 0 {
+		if _, err := s.MoveCard(actor, boardID, card.ID, move); err != nil {
+			if !changed {
+				return false, err
+			}
+			result.Warnings = append(result.Warnings, models.CSVRowError{
+				Row:   row,
+				Error: "карточка обновлена, но не перемещена: " + err.Error(),
+			})
+		}
+	}
+
+	return false, nil
+}
+
+// csvColumns — колонки доски, найденные или созданные при импорте CSV
+type csvColumns struct {
+	byName map[string]*models.Column
+	byID   map[string]*models.Column
+}
+
+func newCSVColumns(tx *gorm.DB, boardID string) (*csvColumns, error) {
+	var columns []models.Column
+	if err := tx.Where("board_id = ?", boardID).Order("rank ASC").Find(&columns).Error; err != nil {
+		return nil, errors.New("ошибка получения колонок")
+	}
+
+	cache := &csvColumns{
+		byName: make(map[string]*models.Column, len(columns)),
+		byID:   make(map[string]*models.Column, len(columns)),
+	}
+	for i := range columns {
+		key := strings.ToLower(strings.TrimSpace(columns[i].Name))
+		// Из колонок с одинаковыми названиями выбирается первая на доске
+		if _, ok := cache.byName[key]; !ok {
+			cache.byName[key] = &columns[i]
+		}
+		cache.byID[columns[i].ID] = &columns[i]
+	}
+	return cache, nil
+}
+
+// csvColumn находит колонку по названию (или ID) и создает её, если на доске такой нет
+func (s *BoardService) csvColumn(actor Actor, boardID, name string, columns *csvColumns, result *models.CSVImportResult) (*models.Column, error) {
+	key := strings.ToLower(name)
+	if column, ok := columns.byName[key]; ok {
+		return column, nil
+	}
+	if column, ok := columns.byID[name]; ok {
+		return column, nil
+	}
+
+	if err := checkLength("название колонки «"+name+"»", name, 100); err != nil {
+		return nil, err
+	}
+	column, err := s.CreateColumn(actor, boardID, models.CreateColumnRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	columns.byName[key] = column
+	columns.byID[column.ID] = column
+	result.ColumnsCreated = append(result.ColumnsCreated, name)
+	return column, nil
+}
+
+// csvFieldPositions сопоставляет поля карточки столбцам CSV. Сопоставление задается
+// парами «поле:заголовок»; остальные поля ищутся по заголовку с названием поля.
+// Заголовки сравниваются без учета регистра.
+func csvFieldPositions(header []string, mapping []string) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+	}
+
+	columns := make(map[string]string, len(csvImportFields))
+	for field := range csvImportFields {
+		columns[field] = field
+	}
+	for _, pair := range mapping {
+		field, name, ok := strings.Cut(pair, ":")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || !csvImportFields[field] {
+			return nil, &ValidationError{Message: "неверное сопоставление «" + pair +
+				"»: ожидается поле:заголовок, поля — id, column, title, description, assignee, deadline и order"}
+		}
+		name = strings.TrimSpace(name)
+		if _, ok := index[strings.ToLower(name)]; !ok {
+			return nil, &ValidationError{Message: "в CSV нет столбца «" + name + "»"}
+		}
+		columns[field] = strings.ToLower(name)
+	}
+
+	positions := make(map[string]int, len(columns))
+	for field, name := range columns {
+		if i, ok := index[name]; ok {
+			positions[field] = i
+		}
+	}
+	return positions, nil
+}
+
+// csvDelimiter определяет разделитель по строке заголовка: табличные редакторы
+// с русской локалью сохраняют CSV через точку с запятой
+func csvDelimiter(data []byte) rune {
+	line := data
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		line = data[:i]
+	}
+	if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+// parseCSVDeadline разбирает дедлайн в формате RFC3339, YYYY-MM-DD HH:MM или YYYY-MM-DD
+func parseCSVDeadline(value string) (*time.Time, error) {
+	for _, layout := range csvDeadlineLayouts {
+		if deadline, err := time.Parse(layout, value); err == nil {
+			return &deadline, nil
+		}
+	}
+	return nil, errors.New("неверный дедлайн «" + value + "»: ожидается YYYY-MM-DD, YYYY-MM-DD HH:MM или RFC3339")
+}
+
+// sameDeadline сообщает, что дедлайны совпадают
+func sameDeadline(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// escapeCSVCell защищает текст от выполнения как формулы в табличном редакторе:
+// перед ячейкой, начинающейся с =, +, -, @ или управляющего символа, ставится апостроф
+func escapeCSVCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// unescapeCSVCell снимает апостроф, поставленный escapeCSVCell
+func unescapeCSVCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
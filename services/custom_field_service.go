@@ -0,0 +1,547 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"task-board/database"
+	"task-board/events"
+	"task-board/models"
+)
+
+// ErrInvalidFilter возвращается для фильтра доски с неизвестным полем или неверным значением
+var ErrInvalidFilter error = &ValidationError{Message: "неверный фильтр по пользовательскому полю"}
+
+// ValidationError сообщает, что запрос не подходит к настройкам доски: значения
+// пользовательских полей карточки или фильтр доски неверны
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// fieldValueError описывает, каким должно быть значение поля
+func fieldValueError(field *models.CustomField, requirement string) error {
+	return &ValidationError{Message: "значение поля «" + field.Name + "» " + requirement}
+}
+
+const (
+	maxCustomFieldOptions = 100
+	maxCustomFieldText    = 1000
+	customFieldDateLayout = "2006-01-02"
+)
+
+type CustomFieldService struct {
+	publisher
+	db *gorm.DB
+}
+
+func NewCustomFieldService(broker events.Broker) *CustomFieldService {
+	return &CustomFieldService{
+		publisher: publisher{broker: broker},
+		db:        database.DB,
+	}
+}
+
+// List возвращает пользовательские поля доски в порядке создания
+func (s *CustomFieldService) List(boardID string) ([]models.CustomField, error) {
+	return loadCustomFields(s.db, boardID)
+}
+
+// Create добавляет пользовательское поле на доску
+func (s *CustomFieldService) Create(actor Actor, boardID string, req models.CustomFieldRequest) (*models.CustomField, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("название поля обязательно")
+	}
+	if !req.Type.Valid() {
+		return nil, errors.New("неизвестный тип поля: допустимы text, number, date, select, multi_select, checkbox и url")
+	}
+	options, err := validateFieldOptions(req.Type, req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	field := &models.CustomField{
+		ID:      generateID(),
+		BoardID: boardID,
+		Name:    name,
+		Type:    req.Type,
+		Options: options,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(field).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("поле с таким названием уже есть")
+			}
+			return errors.New("ошибка создания поля")
+		}
+
+		changes := models.FieldChanges{
+			"custom_field_id": {After: field.ID},
+			"name":            {After: name},
+			"type":            {After: field.Type},
+		}
+		if len(options) > 0 {
+			changes["options"] = models.FieldChange{After: options}
+		}
+		return recordActivity(tx, actor, events.CustomFieldCreated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CustomFieldCreated, actor, boardID, field)
+
+	return field, nil
+}
+
+// Update переименовывает поле или меняет его варианты. Значения карточек
+// с убранными вариантами очищаются.
+func (s *CustomFieldService) Update(actor Actor, boardID, fieldID string, req models.UpdateCustomFieldRequest) (*models.CustomField, error) {
+	var field models.CustomField
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadCustomField(tx, boardID, fieldID, &field); err != nil {
+			return err
+		}
+
+		changes := models.FieldChanges{"custom_field_id": {After: fieldID}}
+
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				return errors.New("название поля обязательно")
+			}
+			if name != field.Name {
+				changes["name"] = models.FieldChange{Before: field.Name, After: name}
+			}
+			field.Name = name
+		}
+
+		var removed []string
+		if req.Options != nil {
+			if !field.Type.HasOptions() {
+				return errors.New("варианты задаются только для полей select и multi_select")
+			}
+			options, err := validateFieldOptions(field.Type, *req.Options)
+			if err != nil {
+				return err
+			}
+
+			kept := make(map[string]struct{}, len(options))
+			for _, option := range options {
+				kept[option] = struct{}{}
+			}
+			for _, option := range field.Options {
+				if _, ok := kept[option]; !ok {
+					removed = append(removed, option)
+				}
+			}
+			if strings.Join(options, "\x00") != strings.Join(field.Options, "\x00") {
+				changes["options"] = models.FieldChange{Before: field.Options, After: options}
+			}
+			field.Options = options
+		}
+
+		if err := tx.Model(&field).Select("name", "options").Updates(&field).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("поле с таким названием уже есть")
+			}
+			return errors.New("ошибка обновления поля")
+		}
+
+		if len(removed) > 0 {
+			if err := clearFieldOptions(tx, &field, removed); err != nil {
+				return err
+			}
+		}
+
+		return recordActivity(tx, actor, events.CustomFieldUpdated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CustomFieldUpdated, actor, boardID, field)
+
+	return &field, nil
+}
+
+// Delete удаляет поле вместе с его значениями у всех карточек доски.
+// Колонки, отсортированные по этому полю, возвращаются к ручному порядку.
+func (s *CustomFieldService) Delete(actor Actor, boardID, fieldID string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var field models.CustomField
+		if err := loadCustomField(tx, boardID, fieldID, &field); err != nil {
+			return err
+		}
+
+		// Unscoped: значения убираются и у карточек в корзине, чтобы восстановленная
+		// карточка не ссылалась на удаленное поле
+		if err := tx.Unscoped().Model(&models.Card{}).
+			Where("board_id = ? AND custom_fields->? IS NOT NULL", boardID, fieldID).
+			UpdateColumn("custom_fields", gorm.Expr("custom_fields - ?", fieldID)).Error; err != nil {
+			return errors.New("ошибка очистки значений поля")
+		}
+
+		if err := tx.Unscoped().Model(&models.Column{}).Where("board_id = ? AND sort_field_id = ?", boardID, fieldID).
+			Updates(map[string]interface{}{"sort_by": models.ColumnSortManual, "sort_field_id": ""}).Error; err != nil {
+			return errors.New("ошибка сброса сортировки колонок")
+		}
+
+		if err := tx.Delete(&field).Error; err != nil {
+			return errors.New("ошибка удаления поля")
+		}
+
+		changes := models.FieldChanges{
+			"custom_field_id": {Before: field.ID},
+			"name":            {Before: field.Name},
+			"type":            {Before: field.Type},
+		}
+		return recordActivity(tx, actor, events.CustomFieldDeleted, boardID, "", "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.CustomFieldDeleted, actor, boardID, deletedPayload{ID: fieldID})
+
+	return nil
+}
+
+// loadCustomField загружает пользовательское поле доски
+func loadCustomField(tx *gorm.DB, boardID, fieldID string, field *models.CustomField) error {
+	if err := tx.First(field, "id = ? AND board_id = ?", fieldID, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("поле не найдено")
+		}
+		return errors.New("ошибка получения поля")
+	}
+	return nil
+}
+
+// loadCustomFields возвращает пользовательские поля доски в порядке создания
+func loadCustomFields(tx *gorm.DB, boardID string) ([]models.CustomField, error) {
+	fields := []models.CustomField{}
+	if err := tx.Where("board_id = ?", boardID).Order("created_at ASC, id ASC").Find(&fields).Error; err != nil {
+		return nil, errors.New("ошибка получения пользовательских полей")
+	}
+	return fields, nil
+}
+
+// validateFieldOptions проверяет варианты поля: они нужны только полям с выбором,
+// не пусты и не повторяются
+func validateFieldOptions(fieldType models.CustomFieldType, requested []string) ([]string, error) {
+	if !fieldType.HasOptions() {
+		if len(requested) > 0 {
+			return nil, errors.New("варианты задаются только для полей select и multi_select")
+		}
+		return nil, nil
+	}
+
+	if len(requested) == 0 {
+		return nil, errors.New("для поля с выбором нужен хотя бы один вариант")
+	}
+	if len(requested) > maxCustomFieldOptions {
+		return nil, errors.New("у поля не может быть больше 100 вариантов")
+	}
+
+	options := make([]string, 0, len(requested))
+	seen := make(map[string]struct{}, len(requested))
+	for _, option := range requested {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return nil, errors.New("вариант поля не может быть пустым")
+		}
+		if _, ok := seen[option]; ok {
+			return nil, errors.New("варианты поля не должны повторяться")
+		}
+		seen[option] = struct{}{}
+		options = append(options, option)
+	}
+	return options, nil
+}
+
+// clearFieldOptions убирает удаленные варианты поля из значений карточек доски
+func clearFieldOptions(tx *gorm.DB, field *models.CustomField, removed []string) error {
+	cards := tx.Unscoped().Model(&models.Card{}).Where("board_id = ?", field.BoardID)
+
+	var err error
+	if field.Type == models.CustomFieldSelect {
+		err = cards.Where("custom_fields->>? IN ?", field.ID, removed).
+			UpdateColumn("custom_fields", gorm.Expr("custom_fields - ?", field.ID)).Error
+	} else {
+		// Из массива multi_select убираем варианты оператором jsonb - text[],
+		// а опустевший массив удаляем вместе с ключом
+		err = cards.Where("custom_fields->? IS NOT NULL", field.ID).
+			UpdateColumn("custom_fields", gorm.Expr(
+				"CASE WHEN jsonb_array_length((custom_fields->?) - ?::text[]) = 0 THEN custom_fields - ? "+
+					"ELSE jsonb_set(custom_fields, ARRAY[?], (custom_fields->?) - ?::text[]) END",
+				field.ID, pgTextArray(removed), field.ID, field.ID, field.ID, pgTextArray(removed))).Error
+	}
+	if err != nil {
+		return errors.New("ошибка очистки значений поля")
+	}
+	return nil
+}
+
+// pgTextArray записывает строки литералом массива PostgreSQL
+func pgTextArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+		quoted[i] = `"` + value + `"`
+	}
+	return "{" + strings.Join(quoted, ",") + "}"
+}
+
+// mergeCustomFields проверяет значения пользовательских полей по их типам и накладывает
+// их на текущие значения карточки. Значение nil (или пустое) очищает поле.
+func mergeCustomFields(tx *gorm.DB, boardID string, current models.CustomFieldValues, requested map[string]interface{}) (models.CustomFieldValues, error) {
+	merged := make(models.CustomFieldValues, len(current)+len(requested))
+	for id, value := range current {
+		merged[id] = value
+	}
+	if len(requested) == 0 {
+		return merged, nil
+	}
+
+	fields, err := loadCustomFields(tx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.CustomField, len(fields))
+	for i := range fields {
+		byID[fields[i].ID] = &fields[i]
+	}
+
+	for id, raw := range requested {
+		field, ok := byID[id]
+		if !ok {
+			return nil, &ValidationError{Message: "пользовательское поле не найдено"}
+		}
+
+		value, err := normalizeFieldValue(field, raw)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			delete(merged, id)
+		} else {
+			merged[id] = value
+		}
+	}
+
+	return merged, nil
+}
+
+// normalizeFieldValue проверяет значение поля и приводит его к виду, в котором оно хранится.
+// Возвращает nil для пустого значения.
+func normalizeFieldValue(field *models.CustomField, raw interface{}) (interface{}, error) {
+	if raw == nil {
+		return nil, nil
+	}
+
+	switch field.Type {
+	case models.CustomFieldText:
+		text, ok := raw.(string)
+		if !ok {
+			return nil, fieldValueError(field, "должно быть строкой")
+		}
+		text = strings.TrimSpace(text)
+		if len([]rune(text)) > maxCustomFieldText {
+			return nil, fieldValueError(field, "длиннее 1000 символов")
+		}
+		if text == "" {
+			return nil, nil
+		}
+		return text, nil
+
+	case models.CustomFieldNumber:
+		number, ok := raw.(float64)
+		if !ok || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fieldValueError(field, "должно быть числом")
+		}
+		return number, nil
+
+	case models.CustomFieldDate:
+		text, ok := raw.(string)
+		if !ok {
+			return nil, fieldValueError(field, "должно быть датой в формате YYYY-MM-DD")
+		}
+		if text == "" {
+			return nil, nil
+		}
+		if _, err := time.Parse(customFieldDateLayout, text); err != nil {
+			return nil, fieldValueError(field, "должно быть датой в формате YYYY-MM-DD")
+		}
+		return text, nil
+
+	case models.CustomFieldSelect:
+		option, ok := raw.(string)
+		if !ok {
+			return nil, fieldValueError(field, "должно быть одним из вариантов")
+		}
+		if option == "" {
+			return nil, nil
+		}
+		if optionIndex(field, option) < 0 {
+			return nil, fieldValueError(field, "должно быть одним из вариантов")
+		}
+		return option, nil
+
+	case models.CustomFieldMultiSelect:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil, fieldValueError(field, "должно быть списком вариантов")
+		}
+		selected := make(map[string]struct{}, len(items))
+		for _, item := range items {
+			option, ok := item.(string)
+			if !ok || optionIndex(field, option) < 0 {
+				return nil, fieldValueError(field, "должно состоять из его вариантов")
+			}
+			selected[option] = struct{}{}
+		}
+		if len(selected) == 0 {
+			return nil, nil
+		}
+		// Варианты храним без повторов в порядке их определения в поле
+		options := make([]string, 0, len(selected))
+		for _, option := range field.Options {
+			if _, ok := selected[option]; ok {
+				options = append(options, option)
+			}
+		}
+		return options, nil
+
+	case models.CustomFieldCheckbox:
+		checked, ok := raw.(bool)
+		if !ok {
+			return nil, fieldValueError(field, "должно быть true или false")
+		}
+		return checked, nil
+
+	case models.CustomFieldURL:
+		text, ok := raw.(string)
+		if !ok {
+			return nil, fieldValueError(field, "должно быть ссылкой")
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, nil
+		}
+		link, err := url.Parse(text)
+		if err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "" ||
+			len(text) > maxCustomFieldText {
+			return nil, fieldValueError(field, "должно быть ссылкой http или https")
+		}
+		return text, nil
+	}
+
+	return nil, errors.New("неизвестный тип поля «" + field.Name + "»")
+}
+
+// optionIndex возвращает позицию варианта в поле или -1
+func optionIndex(field *models.CustomField, option string) int {
+	for i, candidate := range field.Options {
+		if candidate == option {
+			return i
+		}
+	}
+	return -1
+}
+
+// applyCustomFieldFilters добавляет к запросу карточек фильтры по пользовательским полям.
+// Текст и ссылки ищутся по подстроке без учета регистра, multi_select — по вхождению
+// варианта, остальные типы сравниваются на равенство.
+func applyCustomFieldFilters(query *gorm.DB, fields []models.CustomField, filters []string) (*gorm.DB, error) {
+	byID := make(map[string]*models.CustomField, len(fields))
+	for i := range fields {
+		byID[fields[i].ID] = &fields[i]
+	}
+
+	for _, filter := range filters {
+		id, value, ok := strings.Cut(filter, ":")
+		field := byID[id]
+		if !ok || field == nil {
+			return nil, ErrInvalidFilter
+		}
+
+		if value == "" {
+			query = query.Where("custom_fields->? IS NULL", id)
+			continue
+		}
+
+		switch field.Type {
+		case models.CustomFieldText, models.CustomFieldURL:
+			query = query.Where("custom_fields->>? ILIKE ?", id, "%"+escapeLike(value)+"%")
+		case models.CustomFieldNumber:
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			query = query.Where("(custom_fields->>?)::numeric = ?", id, number)
+		case models.CustomFieldDate:
+			if _, err := time.Parse(customFieldDateLayout, value); err != nil {
+				return nil, ErrInvalidFilter
+			}
+			query = query.Where("custom_fields->>? = ?", id, value)
+		case models.CustomFieldSelect:
+			query = query.Where("custom_fields->>? = ?", id, value)
+		case models.CustomFieldMultiSelect:
+			option, _ := json.Marshal([]string{value})
+			query = query.Where("custom_fields->? @> ?::jsonb", id, string(option))
+		case models.CustomFieldCheckbox:
+			checked, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, ErrInvalidFilter
+			}
+			query = query.Where("COALESCE((custom_fields->>?)::boolean, false) = ?", id, checked)
+		}
+	}
+
+	return query, nil
+}
+
+// fieldSortKey возвращает ключ сортировки значения поля; ok = false, если значение не задано.
+// Числа, флажки и варианты сравниваются по num, текст и даты — по str.
+func fieldSortKey(field *models.CustomField, value interface{}) (num float64, str string, ok bool) {
+	switch value := value.(type) {
+	case float64:
+		return value, "", true
+	case bool:
+		// Отмеченные флажки идут первыми
+		if value {
+			return 0, "", true
+		}
+		return 1, "", true
+	case string:
+		if field.Type == models.CustomFieldSelect {
+			return float64(optionIndex(field, value)), "", true
+		}
+		return 0, strings.ToLower(value), true
+	case []interface{}:
+		// Карточки с несколькими вариантами упорядочиваются по первому из них
+		if len(value) > 0 {
+			if option, isString := value[0].(string); isString {
+				return float64(optionIndex(field, option)), "", true
+			}
+		}
+	case []string:
+		if len(value) > 0 {
+			return float64(optionIndex(field, value[0])), "", true
+		}
+	}
+	return 0, "", false
+}
@@ -0,0 +1,937 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"task-board/events"
+	"task-board/lexorank"
+	"task-board/models"
+)
+
+// exportBatchSize — сколько карточек загружается и записывается в поток экспорта за раз
+const exportBatchSize = 200
+
+// ExportBoard проверяет, что доска существует, и возвращает функцию, которая пишет
+// документ экспорта в w. Все данные читаются в одной транзакции REPEATABLE READ,
+// поэтому документ согласован, даже если доску меняют во время выгрузки;
+// карточки загружаются и пишутся пачками, чтобы не собирать большую доску в памяти.
+// В документ не попадают участники, журнал изменений, корзина и содержимое вложений.
+func (s *BoardService) ExportBoard(boardID string) (func(w io.Writer) error, error) {
+	var count int64
+	if err := s.db.Model(&models.Board{}).Where("id = ?", boardID).Count(&count).Error; err != nil {
+		return nil, errors.New("ошибка получения доски")
+	}
+	if count == 0 {
+		return nil, errors.New("доска не найдена")
+	}
+
+	return func(w io.Writer) error {
+		return s.db.Transaction(func(tx *gorm.DB) error {
+			return writeBoardExport(tx, boardID, w)
+		}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}, nil
+}
+
+// writeBoardExport пишет документ экспорта: сначала доску и справочники, затем карточки
+func writeBoardExport(tx *gorm.DB, boardID string, w io.Writer) error {
+	doc, err := loadExportHeader(tx, boardID)
+	if err != nil {
+		return err
+	}
+
+	// Карточки дописываются в поток пачками, поэтому поля документа пишутся
+	// по одному, а массив cards открывается вручную
+	header := []struct {
+		key   string
+		value interface{}
+	}{
+		{"version", doc.Version},
+		{"exported_at", doc.ExportedAt},
+		{"board", doc.Board},
+		{"columns", doc.Columns},
+		{"swimlanes", doc.Swimlanes},
+		{"labels", doc.Labels},
+		{"custom_fields", doc.CustomFields},
+		{"views", doc.Views},
+	}
+	if _, err := io.WriteString(w, "{"); err != nil {
+		return err
+	}
+	for _, field := range header {
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%q:%s,", field.key, value); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, `"cards":[`); err != nil {
+		return err
+	}
+
+	written := 0
+	for offset := 0; ; offset += exportBatchSize {
+		cards, err := loadExportCards(tx, boardID, offset)
+		if err != nil {
+			return err
+		}
+
+		for i := range cards {
+			data, err := json.Marshal(&cards[i])
+			if err != nil {
+				return err
+			}
+			if written > 0 {
+				data = append([]byte{','}, data...)
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			written++
+		}
+
+		if len(cards) < exportBatchSize {
+			break
+		}
+	}
+
+	_, err = w.Write([]byte("]}"))
+	return err
+}
+
+// loadExportHeader загружает доску и её справочники: колонки, дорожки, метки,
+// пользовательские поля и сохраненные представления
+func loadExportHeader(tx *gorm.DB, boardID string) (*models.BoardExport, error) {
+	var board models.Board
+	if err := tx.First(&board, "id = ?", boardID).Error; err != nil {
+		return nil, errors.New("ошибка получения доски")
+	}
+
+	doc := &models.BoardExport{
+		Version:    models.BoardExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Board: models.ExportedBoard{
+			Name:               board.Name,
+			GuestRole:          board.GuestRole,
+			TrashRetentionDays: board.TrashRetentionDays,
+			SwimlaneGrouping:   board.SwimlaneGrouping,
+		},
+		Columns:      []models.ExportedColumn{},
+		Swimlanes:    []models.ExportedSwimlane{},
+		Labels:       []models.ExportedLabel{},
+		CustomFields: []models.ExportedCustomField{},
+		Views:        []models.ExportedView{},
+	}
+
+	var columns []models.Column
+	if err := tx.Where("board_id = ?", boardID).Order("rank ASC").Find(&columns).Error; err != nil {
+		return nil, errors.New("ошибка получения колонок")
+	}
+	for _, column := range columns {
+		doc.Columns = append(doc.Columns, models.ExportedColumn{
+			ID:           column.ID,
+			Name:         column.Name,
+			WIPLimit:     column.WIPLimit,
+			WIPLimitHard: column.WIPLimitHard,
+			SortBy:       column.SortBy,
+			SortFieldID:  column.SortFieldID,
+		})
+	}
+
+	var swimlanes []models.Swimlane
+	if err := tx.Where("board_id = ?", boardID).Order("rank ASC").Find(&swimlanes).Error; err != nil {
+		return nil, errors.New("ошибка получения дорожек")
+	}
+	for _, swimlane := range swimlanes {
+		doc.Swimlanes = append(doc.Swimlanes, models.ExportedSwimlane{
+			ID:        swimlane.ID,
+			Name:      swimlane.Name,
+			Collapsed: swimlane.Collapsed,
+		})
+	}
+
+	var labels []models.Label
+	if err := tx.Where("board_id = ?", boardID).Order("name ASC").Find(&labels).Error; err != nil {
+		return nil, errors.New("ошибка получения меток")
+	}
+	for _, label := range labels {
+		doc.Labels = append(doc.Labels, models.ExportedLabel{ID: label.ID, Name: label.Name, Color: label.Color})
+	}
+
+	fields, err := loadCustomFields(tx, boardID)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		doc.CustomFields = append(doc.CustomFields, models.ExportedCustomField{
+			ID:        field.ID,
+			Name:      field.Name,
+			Type:      field.Type,
+			Options:   field.Options,
+			CreatedAt: field.CreatedAt,
+		})
+	}
+
+	var views []models.SavedView
+	if err := tx.Where("board_id = ?", boardID).Order("name ASC").Find(&views).Error; err != nil {
+		return nil, errors.New("ошибка получения представлений")
+	}
+	for _, view := range views {
+		doc.Views = append(doc.Views, models.ExportedView{Name: view.Name, Query: view.Query, CreatedBy: view.CreatedBy})
+	}
+
+	return doc, nil
+}
+
+// loadExportCards загружает очередную пачку карточек доски с чек-листами, комментариями
+// и вложениями. Карточки идут по колонкам, внутри колонки — по рангу, архивные — последними.
+// Карточки колонок из корзины не выгружаются вместе с самими колонками.
+func loadExportCards(tx *gorm.DB, boardID string, offset int) ([]models.ExportedCard, error) {
+	var cards []models.Card
+	if err := tx.Preload("Labels", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name ASC")
+	}).Joins("JOIN columns ON columns.id = cards.column_id AND columns.deleted_at IS NULL").
+		Where("cards.board_id = ?", boardID).
+		Order("columns.rank ASC, cards.archived_at IS NOT NULL, cards.rank ASC, cards.id ASC").
+		Offset(offset).Limit(exportBatchSize).Find(&cards).Error; err != nil {
+		return nil, errors.New("ошибка получения карточек")
+	}
+	if len(cards) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, len(cards))
+	for i, card := range cards {
+		ids[i] = card.ID
+	}
+
+	var checklists []models.Checklist
+	if err := tx.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("rank ASC")
+	}).Where("card_id IN ?", ids).Order("card_id, rank ASC").Find(&checklists).Error; err != nil {
+		return nil, errors.New("ошибка получения чек-листов")
+	}
+
+	// Удаленные комментарии с ответами выгружаются пустыми, как в CommentService.List,
+	// чтобы ветки обсуждений сохранили форму
+	var all []models.Comment
+	if err := tx.Unscoped().Where("card_id IN ?", ids).Order("created_at ASC, id ASC").Find(&all).Error; err != nil {
+		return nil, errors.New("ошибка получения комментариев")
+	}
+	hasReplies := make(map[string]bool)
+	for _, comment := range all {
+		if comment.ParentID != nil && !comment.DeletedAt.Valid {
+			hasReplies[*comment.ParentID] = true
+		}
+	}
+	var comments []models.Comment
+	for _, comment := range all {
+		if comment.DeletedAt.Valid {
+			if comment.ParentID != nil || !hasReplies[comment.ID] {
+				continue
+			}
+			comment.AuthorID = ""
+			comment.Body = ""
+			comment.EditedAt = nil
+		}
+		comments = append(comments, comment)
+	}
+	commentIDs := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		if !comment.DeletedAt.Valid {
+			commentIDs = append(commentIDs, comment.ID)
+		}
+	}
+	var revisions []models.CommentRevision
+	if len(commentIDs) > 0 {
+		if err := tx.Where("comment_id IN ?", commentIDs).Order("created_at ASC, id ASC").Find(&revisions).Error; err != nil {
+			return nil, errors.New("ошибка получения истории комментариев")
+		}
+	}
+
+	// Имена авторов выгружаются вместе с ID: при импорте комментарии не привязываются
+	// к учетным записям, и автор остается только в тексте
+	authorIDs := make([]string, len(comments))
+	for i, comment := range comments {
+		authorIDs[i] = comment.AuthorID
+	}
+	authors := make(map[string]string)
+	if authorIDs = uniqueStrings(authorIDs); len(authorIDs) > 0 {
+		var users []models.User
+		if err := tx.Select("id", "name").Where("id IN ?", authorIDs).Find(&users).Error; err != nil {
+			return nil, errors.New("ошибка получения авторов комментариев")
+		}
+		for _, user := range users {
+			authors[user.ID] = user.Name
+		}
+	}
+
+	var attachments []models.Attachment
+	if err := tx.Where("card_id IN ?", ids).Order("created_at ASC, id ASC").Find(&attachments).Error; err != nil {
+		return nil, errors.New("ошибка получения вложений")
+	}
+
+	result := make([]models.ExportedCard, len(cards))
+	byID := make(map[string]*models.ExportedCard, len(cards))
+	for i, card := range cards {
+		labelIDs := make([]string, len(card.Labels))
+		for j, label := range card.Labels {
+			labelIDs[j] = label.ID
+		}
+		customFields := card.CustomFields
+		if customFields == nil {
+			customFields = models.CustomFieldValues{}
+		}
+
+		result[i] = models.ExportedCard{
+			ID:           card.ID,
+			ColumnID:     card.ColumnID,
+			SwimlaneID:   card.SwimlaneID,
+			Title:        card.Title,
+			Description:  card.Description,
+			Assignee:     card.Assignee,
+			Deadline:     card.Deadline,
+			Priority:     card.Priority,
+			Estimate:     card.Estimate,
+			LabelIDs:     labelIDs,
+			CustomFields: customFields,
+			CreatedAt:    card.CreatedAt,
+			UpdatedAt:    card.UpdatedAt,
+			ArchivedAt:   card.ArchivedAt,
+			Checklists:   []models.ExportedChecklist{},
+			Comments:     []models.ExportedComment{},
+			Attachments:  []models.ExportedAttachment{},
+		}
+		byID[card.ID] = &result[i]
+	}
+
+	for _, checklist := range checklists {
+		items := make([]models.ExportedChecklistItem, len(checklist.Items))
+		for i, item := range checklist.Items {
+			items[i] = models.ExportedChecklistItem{Text: item.Text, Done: item.Done, Assignee: item.Assignee, DueDate: item.DueDate}
+		}
+		card := byID[checklist.CardID]
+		card.Checklists = append(card.Checklists, models.ExportedChecklist{Title: checklist.Title, Items: items})
+	}
+
+	history := make(map[string][]models.ExportedCommentRevision)
+	for _, revision := range revisions {
+		history[revision.CommentID] = append(history[revision.CommentID], models.ExportedCommentRevision{
+			Body:      revision.Body,
+			EditedBy:  revision.EditedBy,
+			CreatedAt: revision.CreatedAt,
+		})
+	}
+	for _, comment := range comments {
+		card := byID[comment.CardID]
+		card.Comments = append(card.Comments, models.ExportedComment{
+			ID:         comment.ID,
+			ParentID:   comment.ParentID,
+			AuthorID:   comment.AuthorID,
+			AuthorName: authors[comment.AuthorID],
+			Body:       comment.Body,
+			Deleted:    comment.DeletedAt.Valid,
+			CreatedAt:  comment.CreatedAt,
+			EditedAt:   comment.EditedAt,
+			Revisions:  history[comment.ID],
+		})
+	}
+
+	for _, attachment := range attachments {
+		card := byID[attachment.CardID]
+		card.Attachments = append(card.Attachments, models.ExportedAttachment{
+			FileName:    attachment.FileName,
+			ContentType: attachment.ContentType,
+			Size:        attachment.Size,
+			UploadedBy:  attachment.UploadedBy,
+			CreatedAt:   attachment.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// ImportBoard создает новую доску из документа экспорта. Все сущности получают новые ID,
+// ссылки между ними пересчитываются, а порядок берется из порядка элементов документа.
+// Импортирующий становится владельцем доски; авторы комментариев и представлений
+// сохраняются, только если такие пользователи есть на этом сервере. Вложения не
+// переносятся: документ содержит лишь их описание. Доска создается в одной транзакции,
+// поэтому при любой ошибке не остается ничего.
+func (s *BoardService) ImportBoard(actor Actor, doc *models.BoardExport, password string) (*models.Board, error) {
+	if err := validateBoardExport(doc); err != nil {
+		return nil, err
+	}
+
+	var passwordHash []byte
+	if password != "" {
+		var err error
+		passwordHash, err = bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, errors.New("ошибка хеширования пароля")
+		}
+	}
+
+	guestRole := doc.Board.GuestRole
+	if guestRole == "" {
+		guestRole = models.RoleEditor
+	}
+	board := &models.Board{
+		ID:                 generateID(),
+		Name:               strings.TrimSpace(doc.Board.Name),
+		PasswordHash:       string(passwordHash),
+		GuestAccess:        password != "",
+		GuestRole:          guestRole,
+		TrashRetentionDays: doc.Board.TrashRetentionDays,
+		SwimlaneGrouping:   doc.Board.SwimlaneGrouping,
+	}
+	if board.TrashRetentionDays == 0 {
+		board.TrashRetentionDays = defaultTrashRetentionDays
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Select, чтобы GuestAccess=false не заменился значением по умолчанию
+		if err := tx.Select("*").Create(board).Error; err != nil {
+			return errors.New("ошибка создания доски")
+		}
+		if !actor.IsGuest() {
+			member := &models.BoardMember{BoardID: board.ID, UserID: actor.UserID, Role: models.RoleOwner}
+			if err := tx.Create(member).Error; err != nil {
+				return errors.New("ошибка создания доски")
+			}
+		}
+
+		if err := importBoardContent(tx, actor, board.ID, doc); err != nil {
+			return err
+		}
+
+		changes := models.FieldChanges{"name": {After: board.Name}}
+		return recordActivity(tx, actor, events.BoardCreated, board.ID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBoard(board.ID, models.BoardQuery{})
+}
+
+// validateBoardExport проверяет версию документа, значения сущностей и ссылки между ними
+func validateBoardExport(doc *models.BoardExport) error {
+	if doc.Version != models.BoardExportVersion {
+		return &ValidationError{Message: "неподдерживаемая версия документа " + strconv.Itoa(doc.Version) +
+			": ожидается " + strconv.Itoa(models.BoardExportVersion)}
+	}
+
+	name := strings.TrimSpace(doc.Board.Name)
+	if name == "" {
+		return &ValidationError{Message: "имя доски обязательно"}
+	}
+	if err := checkLength("имя доски", name, 255); err != nil {
+		return err
+	}
+	if doc.Board.GuestRole != "" && !doc.Board.GuestRole.Valid() {
+		return &ValidationError{Message: "неизвестная гостевая роль доски"}
+	}
+	if doc.Board.TrashRetentionDays < 0 || doc.Board.TrashRetentionDays > maxTrashRetentionDays {
+		return &ValidationError{Message: "срок хранения корзины должен быть от 1 до 365 дней"}
+	}
+	if !doc.Board.SwimlaneGrouping.Valid() {
+		return &ValidationError{Message: "неизвестная группировка дорожек доски"}
+	}
+
+	fields := make(map[string]*models.CustomField, len(doc.CustomFields))
+	for _, f := range doc.CustomFields {
+		field := &models.CustomField{Name: strings.TrimSpace(f.Name), Type: f.Type}
+		if err := checkExportID("пользовательского поля", f.ID, fields[f.ID] != nil); err != nil {
+			return err
+		}
+		if field.Name == "" {
+			return &ValidationError{Message: "название пользовательского поля обязательно"}
+		}
+		if err := checkLength("название поля «"+field.Name+"»", field.Name, 100); err != nil {
+			return err
+		}
+		if !f.Type.Valid() {
+			return &ValidationError{Message: "у поля «" + field.Name + "» неизвестный тип"}
+		}
+		options, err := validateFieldOptions(f.Type, f.Options)
+		if err != nil {
+			return &ValidationError{Message: "поле «" + field.Name + "»: " + err.Error()}
+		}
+		field.Options = options
+		fields[f.ID] = field
+	}
+
+	columns := make(map[string]bool, len(doc.Columns))
+	for _, column := range doc.Columns {
+		if err := checkExportID("колонки", column.ID, columns[column.ID]); err != nil {
+			return err
+		}
+		columns[column.ID] = true
+		name := strings.TrimSpace(column.Name)
+		if name == "" {
+			return &ValidationError{Message: "имя колонки обязательно"}
+		}
+		if err := checkLength("имя колонки «"+name+"»", name, 100); err != nil {
+			return err
+		}
+		if column.WIPLimit < 0 {
+			return &ValidationError{Message: "WIP-лимит колонки «" + name + "» не может быть отрицательным"}
+		}
+		if !column.SortBy.Valid() {
+			return &ValidationError{Message: "неизвестная сортировка колонки «" + name + "»"}
+		}
+		if column.SortBy == models.ColumnSortField && fields[column.SortFieldID] == nil {
+			return &ValidationError{Message: "колонка «" + name + "» сортируется по неизвестному полю"}
+		}
+	}
+
+	swimlanes := make(map[string]bool, len(doc.Swimlanes))
+	for _, swimlane := range doc.Swimlanes {
+		if err := checkExportID("дорожки", swimlane.ID, swimlanes[swimlane.ID]); err != nil {
+			return err
+		}
+		swimlanes[swimlane.ID] = true
+		name := strings.TrimSpace(swimlane.Name)
+		if name == "" {
+			return &ValidationError{Message: "имя дорожки обязательно"}
+		}
+		if err := checkLength("имя дорожки «"+name+"»", name, 100); err != nil {
+			return err
+		}
+	}
+
+	labels := make(map[string]bool, len(doc.Labels))
+	labelNames := make(map[string]bool, len(doc.Labels))
+	for _, label := range doc.Labels {
+		if err := checkExportID("метки", label.ID, labels[label.ID]); err != nil {
+			return err
+		}
+		labels[label.ID] = true
+		name, _, err := validateLabel(models.LabelRequest{Name: label.Name, Color: label.Color})
+		if err != nil {
+			return &ValidationError{Message: err.Error()}
+		}
+		if err := checkLength("название метки «"+name+"»", name, 100); err != nil {
+			return err
+		}
+		if labelNames[name] {
+			return &ValidationError{Message: "метка «" + name + "» встречается в документе дважды"}
+		}
+		labelNames[name] = true
+	}
+
+	fieldNames := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if fieldNames[field.Name] {
+			return &ValidationError{Message: "поле «" + field.Name + "» встречается в документе дважды"}
+		}
+		fieldNames[field.Name] = true
+	}
+
+	viewNames := make(map[string]bool, len(doc.Views))
+	for _, view := range doc.Views {
+		name, _, err := validateSavedView(models.SavedViewRequest{Name: view.Name, Query: view.Query})
+		if err != nil {
+			return &ValidationError{Message: "представление «" + view.Name + "»: " + err.Error()}
+		}
+		if err := checkLength("название представления «"+name+"»", name, 100); err != nil {
+			return err
+		}
+		if viewNames[name] {
+			return &ValidationError{Message: "представление «" + name + "» встречается в документе дважды"}
+		}
+		viewNames[name] = true
+	}
+
+	cards := make(map[string]bool, len(doc.Cards))
+	for _, card := range doc.Cards {
+		if err := checkExportID("карточки", card.ID, cards[card.ID]); err != nil {
+			return err
+		}
+		cards[card.ID] = true
+		title := strings.TrimSpace(card.Title)
+		if title == "" {
+			return &ValidationError{Message: "заголовок карточки обязателен"}
+		}
+		if err := checkLength("заголовок карточки «"+title+"»", title, 500); err != nil {
+			return err
+		}
+		if err := checkLength("ответственный карточки «"+title+"»", card.Assignee, 255); err != nil {
+			return err
+		}
+		if !columns[card.ColumnID] {
+			return &ValidationError{Message: "карточка «" + title + "» ссылается на неизвестную колонку"}
+		}
+		if card.SwimlaneID != nil && !swimlanes[*card.SwimlaneID] {
+			return &ValidationError{Message: "карточка «" + title + "» ссылается на неизвестную дорожку"}
+		}
+		for _, id := range card.LabelIDs {
+			if !labels[id] {
+				return &ValidationError{Message: "карточка «" + title + "» ссылается на неизвестную метку"}
+			}
+		}
+		if err := validateCardFields(card.Priority, card.Estimate); err != nil {
+			return &ValidationError{Message: "карточка «" + title + "»: " + err.Error()}
+		}
+		for id, raw := range card.CustomFields {
+			field := fields[id]
+			if field == nil {
+				return &ValidationError{Message: "карточка «" + title + "» ссылается на неизвестное поле"}
+			}
+			if _, err := normalizeFieldValue(field, raw); err != nil {
+				return &ValidationError{Message: "карточка «" + title + "»: " + err.Error()}
+			}
+		}
+
+		for _, checklist := range card.Checklists {
+			name := strings.TrimSpace(checklist.Title)
+			if name == "" {
+				return &ValidationError{Message: "название чек-листа карточки «" + title + "» обязательно"}
+			}
+			if err := checkLength("название чек-листа «"+name+"»", name, 255); err != nil {
+				return err
+			}
+			for _, item := range checklist.Items {
+				text := strings.TrimSpace(item.Text)
+				if text == "" {
+					return &ValidationError{Message: "текст пункта чек-листа «" + name + "» обязателен"}
+				}
+				if err := checkLength("пункт чек-листа «"+name+"»", text, 500); err != nil {
+					return err
+				}
+			}
+		}
+
+		comments := make(map[string]bool, len(card.Comments))
+		for _, comment := range card.Comments {
+			if err := checkExportID("комментария", comment.ID, comments[comment.ID]); err != nil {
+				return err
+			}
+			comments[comment.ID] = true
+			if !comment.Deleted && strings.TrimSpace(comment.Body) == "" {
+				return &ValidationError{Message: "пустой комментарий у карточки «" + title + "»"}
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkExportID проверяет, что у сущности документа есть ID и он не повторяется
+func checkExportID(entity, id string, seen bool) error {
+	if id == "" {
+		return &ValidationError{Message: "у " + entity + " в документе нет id"}
+	}
+	if seen {
+		return &ValidationError{Message: "id " + entity + " «" + id + "» повторяется в документе"}
+	}
+	return nil
+}
+
+// checkLength проверяет, что значение не длиннее max символов
+func checkLength(what, value string, max int) error {
+	if len([]rune(value)) > max {
+		return &ValidationError{Message: what + " длиннее " + strconv.Itoa(max) + " символов"}
+	}
+	return nil
+}
+
+// importBoardContent создает на доске boardID сущности проверенного документа,
+// выдавая им новые ID
+func importBoardContent(tx *gorm.DB, actor Actor, boardID string, doc *models.BoardExport) error {
+	ids := make(map[string]string)
+	remap := func(id string) string {
+		if _, ok := ids[id]; !ok {
+			ids[id] = generateID()
+		}
+		return ids[id]
+	}
+	failed := errors.New("ошибка импорта доски")
+
+	fields := make(map[string]*models.CustomField, len(doc.CustomFields))
+	for _, f := range doc.CustomFields {
+		options, _ := validateFieldOptions(f.Type, f.Options)
+		field := &models.CustomField{
+			ID:        remap(f.ID),
+			BoardID:   boardID,
+			Name:      strings.TrimSpace(f.Name),
+			Type:      f.Type,
+			Options:   options,
+			CreatedAt: f.CreatedAt,
+		}
+		if err := tx.Omit(clause.Associations).Create(field).Error; err != nil {
+			return failed
+		}
+		fields[f.ID] = field
+	}
+
+	ranks := lexorank.Spread(len(doc.Columns))
+	for i, c := range doc.Columns {
+		column := &models.Column{
+			ID:           remap(c.ID),
+			BoardID:      boardID,
+			Name:         strings.TrimSpace(c.Name),
+			Rank:         ranks[i],
+			CreatedBy:    actor.UserID,
+			WIPLimit:     c.WIPLimit,
+			WIPLimitHard: c.WIPLimitHard,
+			SortBy:       c.SortBy,
+		}
+		if c.SortBy == models.ColumnSortField {
+			column.SortFieldID = remap(c.SortFieldID)
+		}
+		if err := tx.Omit(clause.Associations).Create(column).Error; err != nil {
+			return failed
+		}
+	}
+
+	ranks = lexorank.Spread(len(doc.Swimlanes))
+	for i, l := range doc.Swimlanes {
+		swimlane := &models.Swimlane{
+			ID:        remap(l.ID),
+			BoardID:   boardID,
+			Name:      strings.TrimSpace(l.Name),
+			Rank:      ranks[i],
+			Collapsed: l.Collapsed,
+		}
+		if err := tx.Omit(clause.Associations).Create(swimlane).Error; err != nil {
+			return failed
+		}
+	}
+
+	for _, l := range doc.Labels {
+		name, color, _ := validateLabel(models.LabelRequest{Name: l.Name, Color: l.Color})
+		label := &models.Label{ID: remap(l.ID), BoardID: boardID, Name: name, Color: color}
+		if err := tx.Omit(clause.Associations).Create(label).Error; err != nil {
+			return failed
+		}
+	}
+
+	for _, v := range doc.Views {
+		name, query, _ := validateSavedView(models.SavedViewRequest{Name: v.Name, Query: v.Query})
+		view := &models.SavedView{ID: generateID(), BoardID: boardID, Name: name, Query: query, CreatedBy: actor.UserID}
+		if err := tx.Omit(clause.Associations).Create(view).Error; err != nil {
+			return failed
+		}
+	}
+
+	// Ранги карточек выдаются по колонкам в порядке документа
+	perColumn := make(map[string]int)
+	for _, card := range doc.Cards {
+		perColumn[card.ColumnID]++
+	}
+	columnRanks := make(map[string][]string, len(perColumn))
+	for columnID, n := range perColumn {
+		columnRanks[columnID] = lexorank.Spread(n)
+	}
+
+	for _, c := range doc.Cards {
+		values := make(models.CustomFieldValues, len(c.CustomFields))
+		for id, raw := range c.CustomFields {
+			if value, _ := normalizeFieldValue(fields[id], raw); value != nil {
+				values[fields[id].ID] = value
+			}
+		}
+
+		card := &models.Card{
+			ID:           remap(c.ID),
+			BoardID:      boardID,
+			Title:        strings.TrimSpace(c.Title),
+			Description:  c.Description,
+			Assignee:     c.Assignee,
+			Deadline:     c.Deadline,
+			Priority:     c.Priority,
+			Estimate:     c.Estimate,
+			ColumnID:     ids[c.ColumnID],
+			Rank:         columnRanks[c.ColumnID][0],
+			CreatedBy:    actor.UserID,
+			UpdatedBy:    actor.UserID,
+			CreatedAt:    c.CreatedAt,
+			UpdatedAt:    c.UpdatedAt,
+			ArchivedAt:   c.ArchivedAt,
+			CustomFields: values,
+			CommentText:  importedCommentText(c.Comments),
+		}
+		columnRanks[c.ColumnID] = columnRanks[c.ColumnID][1:]
+		if c.SwimlaneID != nil {
+			swimlaneID := ids[*c.SwimlaneID]
+			card.SwimlaneID = &swimlaneID
+		}
+		if err := tx.Omit(clause.Associations).Create(card).Error; err != nil {
+			return failed
+		}
+
+		for _, labelID := range uniqueStrings(c.LabelIDs) {
+			link := &models.CardLabel{CardID: card.ID, LabelID: ids[labelID]}
+			if err := tx.Omit(clause.Associations).Create(link).Error; err != nil {
+				return failed
+			}
+		}
+
+		if err := importChecklists(tx, boardID, card.ID, c.Checklists); err != nil {
+			return err
+		}
+		if err := importComments(tx, boardID, card.ID, c.Comments); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importChecklists создает чек-листы карточки в порядке документа
+func importChecklists(tx *gorm.DB, boardID, cardID string, checklists []models.ExportedChecklist) error {
+	ranks := lexorank.Spread(len(checklists))
+	for i, c := range checklists {
+		checklist := &models.Checklist{
+			ID:      generateID(),
+			BoardID: boardID,
+			CardID:  cardID,
+			Title:   strings.TrimSpace(c.Title),
+			Rank:    ranks[i],
+		}
+		if err := tx.Omit(clause.Associations).Create(checklist).Error; err != nil {
+			return errors.New("ошибка импорта чек-листов")
+		}
+
+		itemRanks := lexorank.Spread(len(c.Items))
+		for j, it := range c.Items {
+			item := &models.ChecklistItem{
+				ID:          generateID(),
+				ChecklistID: checklist.ID,
+				Text:        strings.TrimSpace(it.Text),
+				Done:        it.Done,
+				Assignee:    it.Assignee,
+				DueDate:     it.DueDate,
+				Rank:        itemRanks[j],
+			}
+			if err := tx.Omit(clause.Associations).Create(item).Error; err != nil {
+				return errors.New("ошибка импорта чек-листов")
+			}
+		}
+	}
+	return nil
+}
+
+// importComments создает комментарии карточки с историей правок. Ответ на комментарий,
+// которого нет в документе, становится комментарием верхнего уровня, а ответ на ответ
+// привязывается к комментарию верхнего уровня. Удаленные комментарии создаются удаленными,
+// чтобы ответы на них остались в своей ветке. Комментарии и правки создаются без автора:
+// ID пользователей из документа не подтверждены, а имя автора сохраняется в тексте.
+func importComments(tx *gorm.DB, boardID, cardID string, comments []models.ExportedComment) error {
+	parents := make(map[string]*string, len(comments))
+	for _, c := range comments {
+		parents[c.ID] = c.ParentID
+	}
+	root := func(parentID *string) *string {
+		for depth := 0; parentID != nil && depth < len(comments); depth++ {
+			grandparent, ok := parents[*parentID]
+			if !ok {
+				return nil
+			}
+			if grandparent == nil {
+				return parentID
+			}
+			parentID = grandparent
+		}
+		return nil
+	}
+
+	ids := make(map[string]string, len(comments))
+	for _, c := range comments {
+		ids[c.ID] = generateID()
+	}
+
+	for _, c := range comments {
+		comment := &models.Comment{
+			ID:        ids[c.ID],
+			BoardID:   boardID,
+			CardID:    cardID,
+			Body:      importedCommentBody(c),
+			EditedAt:  c.EditedAt,
+			CreatedAt: c.CreatedAt,
+			UpdatedAt: c.CreatedAt,
+		}
+		if c.EditedAt != nil {
+			comment.UpdatedAt = *c.EditedAt
+		}
+		if c.Deleted {
+			comment.Body = ""
+			comment.DeletedAt = gorm.DeletedAt{Time: comment.UpdatedAt, Valid: true}
+		}
+		if parentID := root(c.ParentID); parentID != nil {
+			id := ids[*parentID]
+			comment.ParentID = &id
+		}
+		if err := tx.Omit(clause.Associations).Create(comment).Error; err != nil {
+			return errors.New("ошибка импорта комментариев")
+		}
+
+		if c.Deleted {
+			continue
+		}
+		for _, r := range c.Revisions {
+			revision := &models.CommentRevision{
+				ID:        generateID(),
+				CommentID: comment.ID,
+				Body:      r.Body,
+				CreatedAt: r.CreatedAt,
+			}
+			if err := tx.Omit(clause.Associations).Create(revision).Error; err != nil {
+				return errors.New("ошибка импорта комментариев")
+			}
+		}
+	}
+	return nil
+}
+
+// importedCommentText собирает тексты комментариев для поиска так же, как refreshCommentText
+func importedCommentText(comments []models.ExportedComment) string {
+	sorted := make([]models.ExportedComment, len(comments))
+	copy(sorted, comments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	bodies := make([]string, 0, len(sorted))
+	for _, c := range sorted {
+		if !c.Deleted {
+			bodies = append(bodies, importedCommentBody(c))
+		}
+	}
+	return strings.Join(bodies, "\n")
+}
+
+// importedCommentBody возвращает текст импортируемого комментария с именем автора в начале
+func importedCommentBody(c models.ExportedComment) string {
+	if author := strings.TrimSpace(c.AuthorName); author != "" {
+		return author + ": " + c.Body
+	}
+	return c.Body
+}
+
+// uniqueStrings возвращает непустые значения без повторов в исходном порядке
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
@@ -0,0 +1,224 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"task-board/database"
+	"task-board/events"
+	"task-board/models"
+)
+
+// labelColorPattern — цвет метки в формате #rrggbb
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type LabelService struct {
+	publisher
+	db *gorm.DB
+}
+
+func NewLabelService(broker events.Broker) *LabelService {
+	return &LabelService{
+		publisher: publisher{broker: broker},
+		db:        database.DB,
+	}
+}
+
+// List возвращает каталог меток доски
+func (s *LabelService) List(boardID string) ([]models.Label, error) {
+	labels := []models.Label{}
+
+	if err := s.db.Where("board_id = ?", boardID).Order("name ASC").Find(&labels).Error; err != nil {
+		return nil, errors.New("ошибка получения меток")
+	}
+
+	return labels, nil
+}
+
+// Create добавляет метку в каталог доски
+func (s *LabelService) Create(actor Actor, boardID string, req models.LabelRequest) (*models.Label, error) {
+	name, color, err := validateLabel(req)
+	if err != nil {
+		return nil, err
+	}
+
+	label := &models.Label{
+		ID:      generateID(),
+		BoardID: boardID,
+		Name:    name,
+		Color:   color,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(label).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("метка с таким названием уже есть")
+			}
+			return errors.New("ошибка создания метки")
+		}
+
+		changes := models.FieldChanges{
+			"label_id": {After: label.ID},
+			"name":     {After: name},
+			"color":    {After: color},
+		}
+		return recordActivity(tx, actor, events.LabelCreated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.LabelCreated, actor, boardID, label)
+
+	return label, nil
+}
+
+// Update меняет название и цвет метки
+func (s *LabelService) Update(actor Actor, boardID, labelID string, req models.LabelRequest) (*models.Label, error) {
+	name, color, err := validateLabel(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var label models.Label
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadLabel(tx, boardID, labelID, &label); err != nil {
+			return err
+		}
+
+		changes := models.FieldChanges{"label_id": {After: labelID}}
+		if label.Name != name {
+			changes["name"] = models.FieldChange{Before: label.Name, After: name}
+		}
+		if label.Color != color {
+			changes["color"] = models.FieldChange{Before: label.Color, After: color}
+		}
+
+		if err := tx.Model(&label).Updates(map[string]interface{}{"name": name, "color": color}).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("метка с таким названием уже есть")
+			}
+			return errors.New("ошибка обновления метки")
+		}
+		label.Name = name
+		label.Color = color
+
+		return recordActivity(tx, actor, events.LabelUpdated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.LabelUpdated, actor, boardID, label)
+
+	return &label, nil
+}
+
+// Delete удаляет метку из каталога и снимает её со всех карточек
+func (s *LabelService) Delete(actor Actor, boardID, labelID string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var label models.Label
+		if err := loadLabel(tx, boardID, labelID, &label); err != nil {
+			return err
+		}
+
+		if err := tx.Where("label_id = ?", labelID).Delete(&models.CardLabel{}).Error; err != nil {
+			return errors.New("ошибка снятия метки с карточек")
+		}
+
+		if err := tx.Delete(&label).Error; err != nil {
+			return errors.New("ошибка удаления метки")
+		}
+
+		changes := models.FieldChanges{
+			"label_id": {Before: label.ID},
+			"name":     {Before: label.Name},
+			"color":    {Before: label.Color},
+		}
+		return recordActivity(tx, actor, events.LabelDeleted, boardID, "", "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.LabelDeleted, actor, boardID, deletedPayload{ID: labelID})
+
+	return nil
+}
+
+// validateLabel проверяет и нормализует название и цвет метки
+func validateLabel(req models.LabelRequest) (string, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return "", "", errors.New("название метки обязательно")
+	}
+	if !labelColorPattern.MatchString(req.Color) {
+		return "", "", errors.New("цвет метки должен быть в формате #rrggbb")
+	}
+	return name, strings.ToLower(req.Color), nil
+}
+
+// loadLabel загружает метку доски
+func loadLabel(tx *gorm.DB, boardID, labelID string, label *models.Label) error {
+	if err := tx.First(label, "id = ? AND board_id = ?", labelID, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("метка не найдена")
+		}
+		return errors.New("ошибка получения метки")
+	}
+	return nil
+}
+
+// setCardLabels заменяет метки карточки. Все метки должны принадлежать доске карточки.
+func setCardLabels(tx *gorm.DB, boardID string, card *models.Card, requested []string) error {
+	unique := make(map[string]struct{}, len(requested))
+	for _, id := range requested {
+		unique[id] = struct{}{}
+	}
+
+	labels := []models.Label{}
+	if len(unique) > 0 {
+		ids := make([]string, 0, len(unique))
+		for id := range unique {
+			ids = append(ids, id)
+		}
+		if err := tx.Where("id IN ? AND board_id = ?", ids, boardID).Order("name ASC").Find(&labels).Error; err != nil {
+			return errors.New("ошибка получения меток")
+		}
+		if len(labels) != len(ids) {
+			return errors.New("метка не найдена")
+		}
+	}
+
+	if err := tx.Where("card_id = ?", card.ID).Delete(&models.CardLabel{}).Error; err != nil {
+		return errors.New("ошибка обновления меток карточки")
+	}
+	for _, label := range labels {
+		link := &models.CardLabel{CardID: card.ID, LabelID: label.ID}
+		if err := tx.Omit(clause.Associations).Create(link).Error; err != nil {
+			return errors.New("ошибка обновления меток карточки")
+		}
+	}
+
+	card.Labels = labels
+	return nil
+}
+
+// labelIDs возвращает отсортированные ID меток карточки для журнала изменений
+func labelIDs(labels []models.Label) interface{} {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(labels))
+	for i, label := range labels {
+		ids[i] = label.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
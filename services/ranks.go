@@ -0,0 +1,201 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"task-board/lexorank"
+	"task-board/models"
+)
+
+// rankRetryAttempts — сколько раз повторять операцию, если вычисленный ранг
+// совпал с рангом, одновременно записанным другой транзакцией
+const rankRetryAttempts = 5
+
+// withRankRetry выполняет транзакцию и повторяет её при конфликте уникального ранга.
+// Функция fn должна возвращать gorm.ErrDuplicatedKey без обертки.
+func withRankRetry(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	for attempt := 1; ; attempt++ {
+		err := db.Transaction(fn)
+		if errors.Is(err, gorm.ErrDuplicatedKey) && attempt < rankRetryAttempts {
+			continue
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return errors.New("не удалось определить позицию, попробуйте еще раз")
+		}
+		return err
+	}
+}
+
+// rankForPosition вычисляет ранг для вставки на позицию order (начиная с 1) в упорядоченный
+// по рангу набор строк scope. Значение 0 или позиция за концом набора означает вставку в конец.
+func rankForPosition(scope *gorm.DB, order int) (string, error) {
+	if order > 0 {
+		// Соседи новой позиции: строка перед ней и строка на ней
+		var ranks []string
+		offset := order - 2
+		if offset < 0 {
+			offset = 0
+		}
+		if err := scope.Session(&gorm.Session{}).Order("rank ASC").
+			Offset(offset).Limit(2).Pluck("rank", &ranks).Error; err != nil {
+			return "", err
+		}
+
+		switch {
+		case order == 1 && len(ranks) > 0:
+			return lexorank.Between("", ranks[0]), nil
+		case order > 1 && len(ranks) == 2:
+			return lexorank.Between(ranks[0], ranks[1]), nil
+		}
+	}
+
+	return rankAfterLast(scope)
+}
+
+// rankForGroupPosition вычисляет ранг для вставки на позицию order (начиная с 1) среди строк
+// подмножества group упорядоченного набора scope. Ранг берется вплотную к соседу по подмножеству,
+// чтобы не совпасть с рангами остальных строк набора. Значение 0 или позиция за концом
+// подмножества означает вставку в конец набора.
+func rankForGroupPosition(scope, group *gorm.DB, order int) (string, error) {
+	if order > 0 {
+		var ranks []string
+		offset := order - 2
+		if offset < 0 {
+			offset = 0
+		}
+		if err := group.Session(&gorm.Session{}).Order("rank ASC").
+			Offset(offset).Limit(2).Pluck("rank", &ranks).Error; err != nil {
+			return "", err
+		}
+
+		switch {
+		case order == 1 && len(ranks) > 0:
+			// Перед первой строкой подмножества: сосед слева — ближайшая строка всего набора
+			var prev []string
+			if err := scope.Session(&gorm.Session{}).Where("rank < ?", ranks[0]).Order("rank DESC").
+				Limit(1).Pluck("rank", &prev).Error; err != nil {
+				return "", err
+			}
+			if len(prev) == 0 {
+				return lexorank.Between("", ranks[0]), nil
+			}
+			return lexorank.Between(prev[0], ranks[0]), nil
+		case order > 1 && len(ranks) == 2:
+			// Сразу после предыдущей строки подмножества
+			var next []string
+			if err := scope.Session(&gorm.Session{}).Where("rank > ?", ranks[0]).Order("rank ASC").
+				Limit(1).Pluck("rank", &next).Error; err != nil {
+				return "", err
+			}
+			return lexorank.Between(ranks[0], next[0]), nil
+		}
+	}
+
+	return rankAfterLast(scope)
+}
+
+// rankAfterLast вычисляет ранг для вставки в конец упорядоченного по рангу набора строк
+func rankAfterLast(scope *gorm.DB) (string, error) {
+	var ranks []string
+	if err := scope.Session(&gorm.Session{}).Order("rank DESC").
+		Limit(1).Pluck("rank", &ranks).Error; err != nil {
+		return "", err
+	}
+
+	if len(ranks) == 0 {
+		return lexorank.Between("", ""), nil
+	}
+	return lexorank.Between(ranks[0], ""), nil
+}
+
+// rankedCards возвращает набор карточек колонки, занимающих ранги: архивные карточки
+// в порядке колонки не участвуют
+func rankedCards(tx *gorm.DB, columnID string) *gorm.DB {
+	return tx.Model(&models.Card{}).Where("column_id = ? AND archived_at IS NULL", columnID)
+}
+
+// StartRankRebalancer периодически перебалансирует слишком длинные ранги
+func (s *BoardService) StartRankRebalancer(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.RebalanceRanks(); err != nil {
+				log.Println("Ошибка перебалансировки рангов:", err)
+			}
+		}
+	}()
+}
+
+// RebalanceRanks равномерно перераспределяет ранги в колонках и на досках,
+// где хотя бы один ранг стал длиннее lexorank.MaxLength
+func (s *BoardService) RebalanceRanks() error {
+	var columnIDs []string
+	if err := s.db.Model(&models.Card{}).Where("LENGTH(rank) > ?", lexorank.MaxLength).
+		Distinct().Pluck("column_id", &columnIDs).Error; err != nil {
+		return err
+	}
+
+	for _, columnID := range columnIDs {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			return rebalance(tx, &models.Card{}, "column_id", columnID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var boardIDs []string
+	if err := s.db.Model(&models.Column{}).Where("LENGTH(rank) > ?", lexorank.MaxLength).
+		Distinct().Pluck("board_id", &boardIDs).Error; err != nil {
+		return err
+	}
+
+	for _, boardID := range boardIDs {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			return rebalance(tx, &models.Column{}, "board_id", boardID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(columnIDs)+len(boardIDs) > 0 {
+		log.Printf("Ранги перебалансированы: колонок %d, досок %d", len(columnIDs), len(boardIDs))
+	}
+	return nil
+}
+
+// rebalance переписывает ранги всех строк группы равномерно распределенными значениями
+func rebalance(tx *gorm.DB, model interface{}, groupColumn, groupID string) error {
+	type row struct {
+		ID string
+	}
+	var rows []row
+	if err := tx.Model(model).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").Where(groupColumn+" = ?", groupID).
+		Order("rank ASC").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	// Сначала уводим ранги во временные значения ("~" больше любой цифры),
+	// чтобы новые ранги не столкнулись со старыми на уникальном индексе
+	if err := tx.Model(model).Where(groupColumn+" = ?", groupID).
+		UpdateColumn("rank", gorm.Expr("'~' || id")).Error; err != nil {
+		return err
+	}
+
+	ranks := lexorank.Spread(len(rows))
+	for i, r := range rows {
+		if err := tx.Model(model).Where("id = ?", r.ID).UpdateColumn("rank", ranks[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
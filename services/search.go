@@ -0,0 +1,171 @@
+package services
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"task-board/models"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+
+	// Границы совпадений в фрагментах ts_headline: символы из области для частного
+	// использования не встречаются в тексте, поэтому после экранирования их можно
+	// безопасно заменить на теги <mark>
+	highlightStart = "\ue000"
+	highlightStop  = "\ue001"
+)
+
+var (
+	titleHeadlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", HighlightAll=true"
+	textHeadlineOptions  = "StartSel=" + highlightStart + ", StopSel=" + highlightStop +
+		", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \""
+)
+
+// SearchCards ищет карточки доски по заголовку, описанию и комментариям.
+// Конфигурация russian стеммит кириллицу русским стеммером, а латиницу — английским,
+// поэтому запрос находит обе формы слов. Результаты упорядочены по релевантности:
+// совпадения в заголовке весят больше, чем в описании, а в описании — больше, чем в комментариях.
+func (s *BoardService) SearchCards(boardID string, q models.CardSearchQuery) (*models.CardSearchPage, error) {
+	text := strings.TrimSpace(q.Query)
+	if text == "" {
+		return nil, errors.New("поисковый запрос обязателен")
+	}
+	if !q.Archived.Valid() {
+		return nil, errors.New("неизвестный фильтр архива: допустимы пустой, only и all")
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	tsquery := gorm.Expr("websearch_to_tsquery('russian', ?)", text)
+
+	query := s.db.Model(&models.Card{}).Where("board_id = ? AND search_vector @@ ?", boardID, tsquery)
+
+	switch q.Archived {
+	case models.ArchivedExclude:
+		query = query.Where("archived_at IS NULL")
+	case models.ArchivedOnly:
+		query = query.Where("archived_at IS NOT NULL")
+	}
+	if q.ColumnID != "" {
+		query = query.Where("column_id = ?", q.ColumnID)
+	}
+	if q.Assignee != "" {
+		query = query.Where("assignee = ?", q.Assignee)
+	}
+	if q.LabelID != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM card_labels WHERE card_labels.card_id = cards.id AND card_labels.label_id = ?)",
+			q.LabelID)
+	}
+	if q.DueFrom != "" {
+		from, err := time.Parse(time.RFC3339, q.DueFrom)
+		if err != nil {
+			return nil, errors.New("неверный формат due_from, ожидается RFC3339")
+		}
+		query = query.Where("deadline >= ?", from)
+	}
+	if q.DueTo != "" {
+		to, err := time.Parse(time.RFC3339, q.DueTo)
+		if err != nil {
+			return nil, errors.New("неверный формат due_to, ожидается RFC3339")
+		}
+		query = query.Where("deadline <= ?", to)
+	}
+
+	type match struct {
+		ID                 string
+		Score              float64
+		TitleSnippet       string
+		DescriptionSnippet string
+		CommentSnippet     string
+	}
+
+	// Запрашиваем на одну карточку больше, чтобы понять, есть ли следующая страница.
+	// Фрагменты описания и комментариев строятся только для тех текстов, где есть совпадение.
+	var matches []match
+	if err := query.Select(`id,
+		ts_rank_cd(search_vector, ?) AS score,
+		ts_headline('russian', title, ?, ?) AS title_snippet,
+		CASE WHEN to_tsvector('russian', description) @@ ? THEN ts_headline('russian', description, ?, ?) ELSE '' END AS description_snippet,
+		CASE WHEN to_tsvector('russian', comment_text) @@ ? THEN ts_headline('russian', comment_text, ?, ?) ELSE '' END AS comment_snippet`,
+		tsquery, tsquery, titleHeadlineOptions,
+		tsquery, tsquery, textHeadlineOptions,
+		tsquery, tsquery, textHeadlineOptions).
+		Order("score DESC, updated_at DESC, id ASC").Offset(offset).Limit(limit + 1).
+		Scan(&matches).Error; err != nil {
+		return nil, errors.New("ошибка поиска карточек")
+	}
+
+	page := &models.CardSearchPage{Items: []models.CardSearchResult{}}
+	if len(matches) > limit {
+		matches = matches[:limit]
+		page.NextOffset = offset + limit
+	}
+	if len(matches) == 0 {
+		return page, nil
+	}
+
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+	var cards []models.Card
+	if err := s.db.Preload("Labels", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name ASC")
+	}).Where("id IN ?", ids).Find(&cards).Error; err != nil {
+		return nil, errors.New("ошибка получения карточек")
+	}
+	byID := make(map[string]models.Card, len(cards))
+	for _, card := range cards {
+		byID[card.ID] = card
+	}
+
+	for _, m := range matches {
+		card, ok := byID[m.ID]
+		if !ok {
+			continue
+		}
+		page.Items = append(page.Items, models.CardSearchResult{
+			Card:               card,
+			Score:              m.Score,
+			TitleSnippet:       highlight(m.TitleSnippet),
+			DescriptionSnippet: highlight(m.DescriptionSnippet),
+			CommentSnippet:     highlight(m.CommentSnippet),
+		})
+	}
+
+	return page, nil
+}
+
+// highlight экранирует фрагмент и заменяет границы совпадений тегами <mark>
+func highlight(snippet string) string {
+	return strings.NewReplacer(highlightStart, "<mark>", highlightStop, "</mark>").
+		Replace(html.EscapeString(snippet))
+}
+
+// refreshCommentText пересобирает тексты комментариев карточки, по которым идет поиск
+func refreshCommentText(tx *gorm.DB, cardID string) error {
+	comments := tx.Model(&models.Comment{}).Select("COALESCE(string_agg(body, E'\\n' ORDER BY created_at), '')").
+		Where("card_id = ?", cardID)
+
+	if err := tx.Model(&models.Card{}).Unscoped().Where("id = ?", cardID).
+		UpdateColumn("comment_text", comments).Error; err != nil {
+		return errors.New("ошибка обновления поискового индекса карточки")
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"gorm.io/gorm"
+	"task-board/events"
+	"task-board/models"
+)
+
+// SetColumnSort задает порядок карточек колонки в ответе доски.
+// Ручной порядок карточек при этом сохраняется и возвращается при SortBy = "".
+func (s *BoardService) SetColumnSort(actor Actor, boardID, columnID string, req models.ColumnSortRequest) (*models.Column, error) {
+	if !req.SortBy.Valid() {
+		return nil, errors.New("неизвестный порядок сортировки: допустимы пустой, priority, deadline, created и field")
+	}
+	if req.SortBy == models.ColumnSortField && req.SortFieldID == "" {
+		return nil, errors.New("не указано поле для сортировки")
+	}
+	if req.SortBy != models.ColumnSortField {
+		req.SortFieldID = ""
+	}
+
+	var column models.Column
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND board_id = ?", columnID, boardID).First(&column).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("колонка не найдена")
+			}
+			return errors.New("ошибка получения колонки")
+		}
+		before := columnSnapshot(&column)
+
+		if req.SortFieldID != "" {
+			var field models.CustomField
+			if err := loadCustomField(tx, boardID, req.SortFieldID, &field); err != nil {
+				return err
+			}
+		}
+
+		err := updateVersioned(tx, &column, req.Version, map[string]interface{}{
+			"sort_by":       req.SortBy,
+			"sort_field_id": req.SortFieldID,
+		})
+		if errors.Is(err, errVersionMismatch) {
+			return err
+		}
+		if err != nil {
+			return errors.New("ошибка обновления колонки")
+		}
+
+		if err := tx.First(&column, "id = ?", columnID).Error; err != nil {
+			return errors.New("ошибка перезагрузки колонки")
+		}
+
+		return recordActivity(tx, actor, events.ColumnUpdated, boardID, "", columnID,
+			diffSnapshots(before, columnSnapshot(&column)))
+	})
+	if errors.Is(err, errVersionMismatch) {
+		return nil, s.columnConflict(columnID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ColumnUpdated, actor, boardID, column)
+
+	return &column, nil
+}
+
+// sortColumnCards упорядочивает карточки колонок доски согласно их SortBy.
+// Карточки приходят отсортированными по рангу, поэтому при равенстве ключей
+// стабильная сортировка сохраняет ручной порядок.
+func sortColumnCards(board *models.Board) {
+	fields := make(map[string]*models.CustomField, len(board.CustomFields))
+	for i := range board.CustomFields {
+		fields[board.CustomFields[i].ID] = &board.CustomFields[i]
+	}
+
+	for i := range board.Columns {
+		column := &board.Columns[i]
+		if less := cardLess(column, fields); less != nil {
+			cards := column.Cards
+			sort.SliceStable(cards, func(a, b int) bool {
+				return less(&cards[a], &cards[b])
+			})
+		}
+	}
+}
+
+// cardLess возвращает сравнение карточек для порядка колонки или nil для ручного порядка
+func cardLess(column *models.Column, fields map[string]*models.CustomField) func(a, b *models.Card) bool {
+	switch column.SortBy {
+	case models.ColumnSortPriority:
+		return func(a, b *models.Card) bool {
+			return a.Priority.Weight() > b.Priority.Weight()
+		}
+	case models.ColumnSortDeadline:
+		return func(a, b *models.Card) bool {
+			if a.Deadline == nil || b.Deadline == nil {
+				return a.Deadline != nil && b.Deadline == nil
+			}
+			return a.Deadline.Before(*b.Deadline)
+		}
+	case models.ColumnSortCreated:
+		return func(a, b *models.Card) bool {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+	case models.ColumnSortField:
+		field := fields[column.SortFieldID]
+		if field == nil {
+			return nil
+		}
+		return func(a, b *models.Card) bool {
+			numA, strA, okA := fieldSortKey(field, a.CustomFields[field.ID])
+			numB, strB, okB := fieldSortKey(field, b.CustomFields[field.ID])
+			if !okA || !okB {
+				return okA && !okB
+			}
+			if numA != numB {
+				return numA < numB
+			}
+			return strA < strB
+		}
+	default:
+		return nil
+	}
+}
+
+var (
+	errUnknownPriority  = errors.New("неизвестный приоритет: допустимы low, medium, high и urgent")
+	errNegativeEstimate = errors.New("оценка карточки не может быть отрицательной")
+)
+
+// validateCardFields проверяет приоритет и оценку карточки
+func validateCardFields(priority models.Priority, estimate *float64) error {
+	if !priority.Valid() {
+		return errUnknownPriority
+	}
+	if estimate != nil && *estimate < 0 {
+		return errNegativeEstimate
+	}
+	return nil
+}
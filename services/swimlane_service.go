@@ -0,0 +1,334 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"task-board/database"
+	"task-board/events"
+	"task-board/models"
+)
+
+type SwimlaneService struct {
+	publisher
+	db *gorm.DB
+}
+
+func NewSwimlaneService(broker events.Broker) *SwimlaneService {
+	return &SwimlaneService{
+		publisher: publisher{broker: broker},
+		db:        database.DB,
+	}
+}
+
+// List возвращает дорожки доски по порядку
+func (s *SwimlaneService) List(boardID string) ([]models.Swimlane, error) {
+	swimlanes := []models.Swimlane{}
+
+	if err := s.db.Where("board_id = ?", boardID).Order("rank ASC").Find(&swimlanes).Error; err != nil {
+		return nil, errors.New("ошибка получения дорожек")
+	}
+
+	return swimlanes, nil
+}
+
+// Create добавляет дорожку в конец доски
+func (s *SwimlaneService) Create(actor Actor, boardID string, req models.SwimlaneRequest) (*models.Swimlane, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("название дорожки обязательно")
+	}
+
+	swimlane := &models.Swimlane{
+		ID:      generateID(),
+		BoardID: boardID,
+		Name:    name,
+	}
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		rank, err := rankAfterLast(tx.Model(&models.Swimlane{}).Where("board_id = ?", boardID))
+		if err != nil {
+			return errors.New("ошибка получения порядка дорожек")
+		}
+		swimlane.Rank = rank
+
+		if err := tx.Create(swimlane).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка создания дорожки")
+		}
+
+		changes := models.FieldChanges{
+			"swimlane_id": {After: swimlane.ID},
+			"name":        {After: name},
+		}
+		return recordActivity(tx, actor, events.SwimlaneCreated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.SwimlaneCreated, actor, boardID, swimlane)
+
+	return swimlane, nil
+}
+
+// Update переименовывает дорожку или сворачивает и разворачивает её
+func (s *SwimlaneService) Update(actor Actor, boardID, swimlaneID string, req models.UpdateSwimlaneRequest) (*models.Swimlane, error) {
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("название дорожки обязательно")
+		}
+		updates["name"] = name
+	}
+	if req.Collapsed != nil {
+		updates["collapsed"] = *req.Collapsed
+	}
+
+	var swimlane models.Swimlane
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadSwimlane(tx, boardID, swimlaneID, &swimlane); err != nil {
+			return err
+		}
+
+		changes := models.FieldChanges{"swimlane_id": {After: swimlaneID}}
+		if name, ok := updates["name"]; ok && name != swimlane.Name {
+			changes["name"] = models.FieldChange{Before: swimlane.Name, After: name}
+		}
+		if collapsed, ok := updates["collapsed"]; ok && collapsed != swimlane.Collapsed {
+			changes["collapsed"] = models.FieldChange{Before: swimlane.Collapsed, After: collapsed}
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&swimlane).Updates(updates).Error; err != nil {
+			return errors.New("ошибка обновления дорожки")
+		}
+
+		if err := tx.First(&swimlane, "id = ?", swimlaneID).Error; err != nil {
+			return errors.New("ошибка перезагрузки дорожки")
+		}
+
+		return recordActivity(tx, actor, events.SwimlaneUpdated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.SwimlaneUpdated, actor, boardID, swimlane)
+
+	return &swimlane, nil
+}
+
+// Move перемещает дорожку на позицию Order (начиная с 1, 0 — в конец)
+func (s *SwimlaneService) Move(actor Actor, boardID, swimlaneID string, req models.MoveSwimlaneRequest) (*models.Swimlane, error) {
+	var swimlane models.Swimlane
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		if err := loadSwimlane(tx, boardID, swimlaneID, &swimlane); err != nil {
+			return err
+		}
+
+		rank, err := rankForPosition(tx.Model(&models.Swimlane{}).
+			Where("board_id = ? AND id <> ?", boardID, swimlaneID), req.Order)
+		if err != nil {
+			return errors.New("ошибка получения порядка дорожек")
+		}
+
+		changes := models.FieldChanges{
+			"swimlane_id": {After: swimlaneID},
+			"rank":        {Before: swimlane.Rank, After: rank},
+		}
+
+		if err := tx.Model(&swimlane).Update("rank", rank).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка перемещения дорожки")
+		}
+		swimlane.Rank = rank
+
+		return recordActivity(tx, actor, events.SwimlaneMoved, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.SwimlaneMoved, actor, boardID, swimlane)
+
+	return &swimlane, nil
+}
+
+// Delete удаляет дорожку; её карточки остаются на доске вне дорожек
+func (s *SwimlaneService) Delete(actor Actor, boardID, swimlaneID string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var swimlane models.Swimlane
+		if err := loadSwimlane(tx, boardID, swimlaneID, &swimlane); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&swimlane).Error; err != nil {
+			return errors.New("ошибка удаления дорожки")
+		}
+
+		changes := models.FieldChanges{
+			"swimlane_id": {Before: swimlane.ID},
+			"name":        {Before: swimlane.Name},
+		}
+		return recordActivity(tx, actor, events.SwimlaneDeleted, boardID, "", "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.SwimlaneDeleted, actor, boardID, deletedPayload{ID: swimlaneID})
+
+	return nil
+}
+
+// SetGrouping задает, по какому признаку карточки доски делятся на дорожки
+func (s *SwimlaneService) SetGrouping(actor Actor, boardID string, req models.SwimlaneGroupingRequest) error {
+	if !req.Grouping.Valid() {
+		return errors.New("неизвестная группировка дорожек: допустимы пустая, assignee и label")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var board models.Board
+		if err := tx.Select("id", "swimlane_grouping").First(&board, "id = ?", boardID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("доска не найдена")
+			}
+			return errors.New("ошибка получения доски")
+		}
+
+		if err := tx.Model(&board).Update("swimlane_grouping", req.Grouping).Error; err != nil {
+			return errors.New("ошибка обновления доски")
+		}
+
+		changes := models.FieldChanges{}
+		if board.SwimlaneGrouping != req.Grouping {
+			changes["swimlane_grouping"] = models.FieldChange{Before: board.SwimlaneGrouping, After: req.Grouping}
+		}
+		return recordActivity(tx, actor, events.BoardUpdated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.BoardUpdated, actor, boardID, req)
+
+	return nil
+}
+
+// loadSwimlane загружает дорожку доски
+func loadSwimlane(tx *gorm.DB, boardID, swimlaneID string, swimlane *models.Swimlane) error {
+	if err := tx.First(swimlane, "id = ? AND board_id = ?", swimlaneID, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("дорожка не найдена")
+		}
+		return errors.New("ошибка получения дорожки")
+	}
+	return nil
+}
+
+// buildLanes раскладывает карточки доски по дорожкам согласно группировке доски.
+// При группировке по меткам карточка с несколькими метками попадает в несколько дорожек.
+// Карточки вне групп собираются в последнюю дорожку с пустым ключом.
+func buildLanes(tx *gorm.DB, board *models.Board) error {
+	var lanes []models.Lane
+	rest := "Без дорожки"
+
+	switch board.SwimlaneGrouping {
+	case models.SwimlaneGroupingAssignee:
+		assignees := map[string]struct{}{}
+		for _, column := range board.Columns {
+			for _, card := range column.Cards {
+				if card.Assignee != "" {
+					assignees[card.Assignee] = struct{}{}
+				}
+			}
+		}
+		names := make([]string, 0, len(assignees))
+		for name := range assignees {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		rest = "Без ответственного"
+		for _, name := range names {
+			lanes = append(lanes, models.Lane{Key: name, Name: name})
+		}
+	case models.SwimlaneGroupingLabel:
+		var labels []models.Label
+		if err := tx.Where("board_id = ?", board.ID).Order("name ASC").Find(&labels).Error; err != nil {
+			return errors.New("ошибка получения меток")
+		}
+		rest = "Без меток"
+		for _, label := range labels {
+			lanes = append(lanes, models.Lane{Key: label.ID, Name: label.Name, Color: label.Color})
+		}
+	default:
+		var swimlanes []models.Swimlane
+		if err := tx.Where("board_id = ?", board.ID).Order("rank ASC").Find(&swimlanes).Error; err != nil {
+			return errors.New("ошибка получения дорожек")
+		}
+		for _, swimlane := range swimlanes {
+			lanes = append(lanes, models.Lane{Key: swimlane.ID, Name: swimlane.Name, Collapsed: swimlane.Collapsed})
+		}
+	}
+	lanes = append(lanes, models.Lane{Key: "", Name: rest})
+
+	index := make(map[string]int, len(lanes))
+	for i := range lanes {
+		lanes[i].Cells = make(map[string][]string, len(board.Columns))
+		for _, column := range board.Columns {
+			lanes[i].Cells[column.ID] = []string{}
+		}
+		index[lanes[i].Key] = i
+	}
+
+	for _, column := range board.Columns {
+		for _, card := range column.Cards {
+			keys := laneKeys(board.SwimlaneGrouping, &card)
+			placed := false
+			for _, key := range keys {
+				if i, ok := index[key]; ok && key != "" {
+					lanes[i].Cells[column.ID] = append(lanes[i].Cells[column.ID], card.ID)
+					placed = true
+				}
+			}
+			if !placed {
+				last := &lanes[len(lanes)-1]
+				last.Cells[column.ID] = append(last.Cells[column.ID], card.ID)
+			}
+		}
+	}
+
+	board.Lanes = lanes
+	return nil
+}
+
+// laneKeys возвращает ключи дорожек, в которые попадает карточка
+func laneKeys(grouping models.SwimlaneGrouping, card *models.Card) []string {
+	switch grouping {
+	case models.SwimlaneGroupingAssignee:
+		return []string{card.Assignee}
+	case models.SwimlaneGroupingLabel:
+		keys := make([]string, len(card.Labels))
+		for i, label := range card.Labels {
+			keys[i] = label.ID
+		}
+		return keys
+	default:
+		if card.SwimlaneID == nil {
+			return nil
+		}
+		return []string{*card.SwimlaneID}
+	}
+}
@@ -0,0 +1,341 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"task-board/events"
+	"task-board/lexorank"
+	"task-board/models"
+)
+
+// ErrColumnInTrash возвращается при восстановлении карточки, колонка которой тоже в корзине
+var ErrColumnInTrash = errors.New("колонка карточки в корзине, сначала восстановите колонку")
+
+const (
+	// defaultTrashRetentionDays — срок хранения корзины новой доски
+	defaultTrashRetentionDays = 30
+	// maxTrashRetentionDays — наибольший срок хранения корзины, который можно задать доске
+	maxTrashRetentionDays = 365
+)
+
+// ListTrash возвращает содержимое корзины доски от недавно удаленного к давнему.
+// Карточки, удаленные вместе с колонкой, вложены в неё; остальные перечислены отдельно.
+func (s *BoardService) ListTrash(boardID string) (*models.TrashResponse, error) {
+	var board models.Board
+	if err := s.db.Select("id", "trash_retention_days").First(&board, "id = ?", boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("доска не найдена")
+		}
+		return nil, errors.New("ошибка получения доски")
+	}
+
+	columns := []models.Column{}
+	if err := s.db.Unscoped().Where("board_id = ? AND deleted_at IS NOT NULL", boardID).
+		Order("deleted_at DESC").Find(&columns).Error; err != nil {
+		return nil, errors.New("ошибка получения корзины")
+	}
+
+	var cards []models.Card
+	if err := s.db.Unscoped().Preload("Labels").Where("board_id = ? AND deleted_at IS NOT NULL", boardID).
+		Order("deleted_at DESC").Find(&cards).Error; err != nil {
+		return nil, errors.New("ошибка получения корзины")
+	}
+
+	columnIndex := make(map[string]int, len(columns))
+	for i := range columns {
+		columns[i].Cards = []models.Card{}
+		columnIndex[columns[i].ID] = i
+	}
+
+	response := &models.TrashResponse{
+		RetentionDays: board.TrashRetentionDays,
+		Columns:       columns,
+		Cards:         []models.Card{},
+	}
+	for _, card := range cards {
+		if i, ok := columnIndex[card.ColumnID]; ok && card.DeletedAt.Time.Equal(columns[i].DeletedAt.Time) {
+			columns[i].Cards = append(columns[i].Cards, card)
+			continue
+		}
+		response.Cards = append(response.Cards, card)
+	}
+
+	return response, nil
+}
+
+// RestoreCard возвращает карточку из корзины на прежнее место в её колонке.
+// Если место заняли, карточка встает сразу после занявшей его.
+func (s *BoardService) RestoreCard(actor Actor, boardID, cardID string) (*models.Card, error) {
+	var card models.Card
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ? AND board_id = ? AND deleted_at IS NOT NULL", cardID, boardID).
+			First(&card).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("карточка не найдена в корзине")
+			}
+			return errors.New("ошибка получения карточки")
+		}
+
+		var count int64
+		if err := tx.Model(&models.Column{}).Where("id = ? AND board_id = ?", card.ColumnID, boardID).
+			Count(&count).Error; err != nil {
+			return errors.New("ошибка получения колонки")
+		}
+		if count == 0 {
+			return ErrColumnInTrash
+		}
+
+		// Архивная карточка в WIP-лимит не входит
+		adding := 0
+		if card.ArchivedAt == nil {
+			adding = 1
+		}
+		overLimit, err := checkWIPLimit(tx, card.ColumnID, adding)
+		if err != nil {
+			return err
+		}
+
+		rank, err := freeRank(rankedCards(tx, card.ColumnID), card.Rank)
+		if err != nil {
+			return errors.New("ошибка получения порядка карточек")
+		}
+
+		if err := tx.Unscoped().Model(&card).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"rank":       rank,
+			"version":    gorm.Expr("version + 1"),
+			"updated_by": actor.UserID,
+		}).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка восстановления карточки")
+		}
+
+		if err := tx.Preload("Labels").First(&card, "id = ?", cardID).Error; err != nil {
+			return errors.New("ошибка перезагрузки карточки")
+		}
+		card.WIPLimitExceeded = overLimit
+
+		changes := diffSnapshots(nil, cardSnapshot(&card))
+		if overLimit {
+			changes["wip_limit_exceeded"] = models.FieldChange{After: true}
+		}
+		return recordActivity(tx, actor, events.CardRestored, boardID, cardID, card.ColumnID, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.CardRestored, actor, boardID, card)
+
+	return &card, nil
+}
+
+// RestoreColumn возвращает колонку из корзины на прежнее место вместе с карточками,
+// удаленными вместе с ней
+func (s *BoardService) RestoreColumn(actor Actor, boardID, columnID string) (*models.Column, error) {
+	var column models.Column
+
+	err := withRankRetry(s.db, func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("id = ? AND board_id = ? AND deleted_at IS NOT NULL", columnID, boardID).
+			First(&column).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("колонка не найдена в корзине")
+			}
+			return errors.New("ошибка получения колонки")
+		}
+		deletedAt := column.DeletedAt.Time
+
+		rank, err := freeRank(tx.Model(&models.Column{}).Where("board_id = ?", boardID), column.Rank)
+		if err != nil {
+			return errors.New("ошибка получения порядка колонок")
+		}
+
+		if err := tx.Unscoped().Model(&column).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"rank":       rank,
+			"version":    gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return err
+			}
+			return errors.New("ошибка восстановления колонки")
+		}
+
+		// Возвращаемые карточки проверяются по WIP-лимиту колонки, как при переносе
+		var adding int64
+		if err := tx.Unscoped().Model(&models.Card{}).
+			Where("column_id = ? AND deleted_at = ? AND archived_at IS NULL", columnID, deletedAt).
+			Count(&adding).Error; err != nil {
+			return errors.New("ошибка подсчета карточек колонки")
+		}
+		overLimit, err := checkWIPLimit(tx, columnID, int(adding))
+		if err != nil {
+			return err
+		}
+
+		// Пока колонка была в корзине, в неё нельзя было добавить карточки,
+		// поэтому ранги восстановленных карточек не пересекаются
+		result := tx.Unscoped().Model(&models.Card{}).
+			Where("column_id = ? AND deleted_at = ?", columnID, deletedAt).
+			Update("deleted_at", nil)
+		if result.Error != nil {
+			return errors.New("ошибка восстановления карточек колонки")
+		}
+
+		if err := tx.Preload("Cards.Labels").Preload("Cards", func(db *gorm.DB) *gorm.DB {
+			return db.Order("rank ASC")
+		}).First(&column, "id = ?", columnID).Error; err != nil {
+			return errors.New("ошибка перезагрузки колонки")
+		}
+		column.OverWIPLimit = overLimit
+
+		changes := diffSnapshots(nil, columnSnapshot(&column))
+		changes["cards"] = models.FieldChange{After: result.RowsAffected}
+		if overLimit {
+			changes["wip_limit_exceeded"] = models.FieldChange{After: true}
+		}
+		return recordActivity(tx, actor, events.ColumnRestored, boardID, "", columnID, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ColumnRestored, actor, boardID, column)
+
+	return &column, nil
+}
+
+// SetTrashRetention задает, сколько дней удаленные карточки и колонки хранятся в корзине
+func (s *BoardService) SetTrashRetention(actor Actor, boardID string, req models.TrashRetentionRequest) error {
+	if req.Days < 1 || req.Days > maxTrashRetentionDays {
+		return errors.New("срок хранения корзины должен быть от 1 до 365 дней")
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var board models.Board
+		if err := tx.Select("id", "trash_retention_days").First(&board, "id = ?", boardID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("доска не найдена")
+			}
+			return errors.New("ошибка получения доски")
+		}
+
+		if err := tx.Model(&board).Update("trash_retention_days", req.Days).Error; err != nil {
+			return errors.New("ошибка обновления доски")
+		}
+
+		changes := models.FieldChanges{}
+		if board.TrashRetentionDays != req.Days {
+			changes["trash_retention_days"] = models.FieldChange{Before: board.TrashRetentionDays, After: req.Days}
+		}
+		return recordActivity(tx, actor, events.BoardUpdated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.BoardUpdated, actor, boardID, req)
+
+	return nil
+}
+
+// StartTrashPurger периодически окончательно удаляет карточки и колонки,
+// пролежавшие в корзине дольше срока хранения своей доски
+func (s *BoardService) StartTrashPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.PurgeTrash(); err != nil {
+				log.Println("Ошибка очистки корзины:", err)
+			}
+		}
+	}()
+}
+
+// PurgeTrash окончательно удаляет просроченные элементы корзины всех досок
+// вместе с файлами их вложений
+func (s *BoardService) PurgeTrash() error {
+	const expired = "deleted_at < NOW() - (SELECT trash_retention_days FROM boards WHERE boards.id = board_id) * INTERVAL '1 day'"
+
+	var blobs []string
+	var cardCount, columnCount int64
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var cardIDs, columnIDs []string
+		if err := tx.Unscoped().Model(&models.Card{}).Where(expired).Pluck("id", &cardIDs).Error; err != nil {
+			return err
+		}
+		// Колонка с архивными карточками хранится, пока их не перенесут: архив не удаляется
+		if err := tx.Unscoped().Model(&models.Column{}).Where(expired).
+			Where("NOT EXISTS (SELECT 1 FROM cards WHERE cards.column_id = columns.id AND cards.deleted_at IS NULL)").
+			Pluck("id", &columnIDs).Error; err != nil {
+			return err
+		}
+		if len(cardIDs)+len(columnIDs) == 0 {
+			return nil
+		}
+
+		// Карточки просроченных колонок удалятся каскадом вместе с колонками
+		var err error
+		blobs, err = attachmentKeys(tx, "card_id IN ? OR card_id IN (SELECT id FROM cards WHERE column_id IN ?)",
+			append(cardIDs, ""), append(columnIDs, ""))
+		if err != nil {
+			return err
+		}
+
+		result := tx.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", cardIDs).Delete(&models.Card{})
+		if result.Error != nil {
+			return result.Error
+		}
+		cardCount = result.RowsAffected
+
+		result = tx.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL", columnIDs).Delete(&models.Column{})
+		if result.Error != nil {
+			return result.Error
+		}
+		columnCount = result.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	removeBlobs(s.store, blobs)
+
+	if cardCount+columnCount > 0 {
+		log.Printf("Корзина очищена: карточек %d, колонок %d", cardCount, columnCount)
+	}
+	return nil
+}
+
+// freeRank возвращает rank, если он свободен в упорядоченном наборе строк scope,
+// иначе — ранг сразу после строки, занявшей его
+func freeRank(scope *gorm.DB, rank string) (string, error) {
+	var count int64
+	if err := scope.Session(&gorm.Session{}).Where("rank = ?", rank).Count(&count).Error; err != nil {
+		return "", err
+	}
+	if count == 0 {
+		return rank, nil
+	}
+
+	var next []string
+	if err := scope.Session(&gorm.Session{}).Where("rank > ?", rank).Order("rank ASC").
+		Limit(1).Pluck("rank", &next).Error; err != nil {
+		return "", err
+	}
+
+	if len(next) == 0 {
+		return lexorank.Between(rank, ""), nil
+	}
+	return lexorank.Between(rank, next[0]), nil
+}
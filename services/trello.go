@@ -0,0 +1,402 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-board/models"
+)
+
+// Структуры экспорта доски Trello (Menu → Print, export and share → Export as JSON).
+// Разбираются только поля, которые переносятся на доску.
+type trelloBoard struct {
+	Name       string            `json:"name"`
+	Lists      []trelloList      `json:"lists"`
+	Cards      []trelloCard      `json:"cards"`
+	Labels     []trelloLabel     `json:"labels"`
+	Checklists []trelloChecklist `json:"checklists"`
+	Members    []trelloMember    `json:"members"`
+	Actions    []trelloAction    `json:"actions"`
+}
+
+type trelloList struct {
+	ID     string  `json:"id"`
+	Name   string  `json:"name"`
+	Closed bool    `json:"closed"`
+	Pos    float64 `json:"pos"`
+}
+
+type trelloCard struct {
+	ID               string     `json:"id"`
+	Name             string     `json:"name"`
+	Desc             string     `json:"desc"`
+	IDList           string     `json:"idList"`
+	Closed           bool       `json:"closed"`
+	Pos              float64    `json:"pos"`
+	Due              *time.Time `json:"due"`
+	DateLastActivity *time.Time `json:"dateLastActivity"`
+	IDLabels         []string   `json:"idLabels"`
+	IDMembers        []string   `json:"idMembers"`
+	Attachments      []struct{} `json:"attachments"`
+}
+
+type trelloLabel struct {
+	ID    string  `json:"id"`
+	Name  string  `json:"name"`
+	Color *string `json:"color"`
+}
+
+type trelloChecklist struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	IDCard     string            `json:"idCard"`
+	Pos        float64           `json:"pos"`
+	CheckItems []trelloCheckItem `json:"checkItems"`
+}
+
+type trelloCheckItem struct {
+	Name     string     `json:"name"`
+	State    string     `json:"state"`
+	Pos      float64    `json:"pos"`
+	Due      *time.Time `json:"due"`
+	IDMember string     `json:"idMember"`
+}
+
+type trelloMember struct {
+	ID       string `json:"id"`
+	FullName string `json:"fullName"`
+	Username string `json:"username"`
+}
+
+type trelloAction struct {
+	ID            string       `json:"id"`
+	Type          string       `json:"type"`
+	Date          time.Time    `json:"date"`
+	MemberCreator trelloMember `json:"memberCreator"`
+	Data          struct {
+		Text string `json:"text"`
+		Card struct {
+			ID string `json:"id"`
+		} `json:"card"`
+	} `json:"data"`
+}
+
+// trelloColors — цвета меток Trello; оттенки вроде green_dark сводятся к основному цвету
+var trelloColors = map[string]string{
+	"green":  "#61bd4f",
+	"yellow": "#f2d600",
+	"orange": "#ff9f1a",
+	"red":    "#eb5a46",
+	"purple": "#c377e0",
+	"blue":   "#0079bf",
+	"sky":    "#00c2e0",
+	"lime":   "#51e898",
+	"pink":   "#ff78cb",
+	"black":  "#344563",
+}
+
+// trelloNoColor — цвет меток Trello без цвета
+const trelloNoColor = "#b3bac5"
+
+// ImportTrello создает доску из JSON-экспорта доски Trello. Списки становятся колонками,
+// карточки — карточками с дедлайнами, метками, чек-листами и комментариями, первый участник
+// карточки — ответственным, а закрытые карточки и карточки закрытых списков — архивными.
+// С dryRun доска не создается: возвращается только отчет о том, что было бы создано.
+func (s *BoardService) ImportTrello(actor Actor, data []byte, password string, dryRun bool) (*models.TrelloImportResult, error) {
+	var trello trelloBoard
+	if err := json.Unmarshal(data, &trello); err != nil {
+		return nil, &ValidationError{Message: "неверный формат экспорта Trello"}
+	}
+	if trello.Lists == nil || trello.Cards == nil {
+		return nil, &ValidationError{Message: "документ не похож на экспорт доски Trello: нет списков и карточек"}
+	}
+
+	doc, report := convertTrelloBoard(&trello)
+	if err := validateBoardExport(doc); err != nil {
+		return nil, err
+	}
+
+	result := &models.TrelloImportResult{DryRun: dryRun, Report: *report}
+	if dryRun {
+		return result, nil
+	}
+
+	board, err := s.ImportBoard(actor, doc, password)
+	if err != nil {
+		return nil, err
+	}
+	result.Board = board
+	return result, nil
+}
+
+// convertTrelloBoard переводит доску Trello в документ экспорта и составляет отчет об импорте
+func convertTrelloBoard(trello *trelloBoard) (*models.BoardExport, *models.TrelloImportReport) {
+	report := &models.TrelloImportReport{Assignees: []string{}, Warnings: []string{}}
+	warn := func(message string) {
+		report.Warnings = append(report.Warnings, message)
+	}
+	truncated := 0
+	clip := func(value string, max int) string {
+		runes := []rune(value)
+		if len(runes) <= max {
+			return value
+		}
+		truncated++
+		return string(runes[:max])
+	}
+
+	name := strings.TrimSpace(trello.Name)
+	if name == "" {
+		name = "Доска из Trello"
+	}
+	doc := &models.BoardExport{
+		Version: models.BoardExportVersion,
+		Board:   models.ExportedBoard{Name: clip(name, 255)},
+	}
+	report.Board = doc.Board.Name
+
+	members := make(map[string]string, len(trello.Members))
+	for _, member := range trello.Members {
+		members[member.ID] = trelloMemberName(member)
+	}
+
+	// Метки с одинаковыми названиями объединяются: на доске названия меток уникальны
+	labelIDs := make(map[string]string, len(trello.Labels))
+	byName := make(map[string]string, len(trello.Labels))
+	for _, l := range trello.Labels {
+		color := trelloNoColor
+		colorName := ""
+		if l.Color != nil {
+			colorName = strings.SplitN(*l.Color, "_", 2)[0]
+			if hex, ok := trelloColors[colorName]; ok {
+				color = hex
+			}
+		}
+		name := strings.TrimSpace(l.Name)
+		if name == "" {
+			name = colorName
+		}
+		if name == "" {
+			name = "без цвета"
+		}
+		name = clip(name, 100)
+
+		if id, ok := byName[name]; ok {
+			labelIDs[l.ID] = id
+			continue
+		}
+		byName[name] = l.ID
+		labelIDs[l.ID] = l.ID
+		doc.Labels = append(doc.Labels, models.ExportedLabel{ID: l.ID, Name: name, Color: color})
+	}
+	if merged := len(trello.Labels) - len(doc.Labels); merged > 0 {
+		warn("объединено меток с повторяющимися названиями: " + strconv.Itoa(merged))
+	}
+
+	lists := make([]trelloList, len(trello.Lists))
+	copy(lists, trello.Lists)
+	sort.SliceStable(lists, func(i, j int) bool { return lists[i].Pos < lists[j].Pos })
+
+	cardsPerList := make(map[string]int, len(lists))
+	for _, card := range trello.Cards {
+		cardsPerList[card.IDList]++
+	}
+
+	listIndex := make(map[string]int, len(lists))
+	closedLists := make(map[string]bool)
+	for _, list := range lists {
+		// Закрытые списки без карточек не переносятся, а с карточками становятся
+		// колонками с архивными карточками
+		if list.Closed {
+			if cardsPerList[list.ID] == 0 {
+				continue
+			}
+			closedLists[list.ID] = true
+			warn("список «" + list.Name + "» в архиве Trello: его карточки перенесены в архив колонки с тем же названием")
+		}
+		name := strings.TrimSpace(list.Name)
+		if name == "" {
+			name = "Без названия"
+		}
+		listIndex[list.ID] = len(doc.Columns)
+		doc.Columns = append(doc.Columns, models.ExportedColumn{ID: list.ID, Name: clip(name, 100)})
+	}
+	report.Columns = len(doc.Columns)
+
+	checklists := make(map[string][]trelloChecklist)
+	for _, checklist := range trello.Checklists {
+		checklists[checklist.IDCard] = append(checklists[checklist.IDCard], checklist)
+	}
+
+	comments := make(map[string][]trelloAction)
+	for _, action := range trello.Actions {
+		if action.Type == "commentCard" && strings.TrimSpace(action.Data.Text) != "" {
+			comments[action.Data.Card.ID] = append(comments[action.Data.Card.ID], action)
+		}
+	}
+
+	// Карточки идут по спискам, внутри списка — по позиции, закрытые — последними
+	cards := make([]trelloCard, 0, len(trello.Cards))
+	skipped := 0
+	for _, card := range trello.Cards {
+		if _, ok := listIndex[card.IDList]; !ok {
+			skipped++
+			continue
+		}
+		cards = append(cards, card)
+	}
+	if skipped > 0 {
+		warn("пропущено карточек без списка: " + strconv.Itoa(skipped))
+	}
+	sort.SliceStable(cards, func(i, j int) bool {
+		a, b := cards[i], cards[j]
+		if listIndex[a.IDList] != listIndex[b.IDList] {
+			return listIndex[a.IDList] < listIndex[b.IDList]
+		}
+		if a.Closed != b.Closed {
+			return !a.Closed
+		}
+		return a.Pos < b.Pos
+	})
+
+	assignees := make(map[string]bool)
+	multipleMembers, attachments := 0, 0
+	for _, c := range cards {
+		title := strings.TrimSpace(c.Name)
+		if title == "" {
+			title = "Без названия"
+		}
+		card := models.ExportedCard{
+			ID:           c.ID,
+			ColumnID:     c.IDList,
+			Title:        clip(title, 500),
+			Description:  c.Desc,
+			Deadline:     c.Due,
+			LabelIDs:     []string{},
+			CustomFields: models.CustomFieldValues{},
+			CreatedAt:    trelloCreatedAt(c.ID),
+		}
+		if c.DateLastActivity != nil {
+			card.UpdatedAt = *c.DateLastActivity
+		}
+		if c.Closed || closedLists[c.IDList] {
+			archivedAt := card.UpdatedAt
+			if archivedAt.IsZero() {
+				archivedAt = time.Now()
+			}
+			card.ArchivedAt = &archivedAt
+			report.ArchivedCards++
+		}
+
+		for _, id := range c.IDMembers {
+			if name := members[id]; name != "" {
+				card.Assignee = clip(name, 255)
+				assignees[card.Assignee] = true
+				break
+			}
+		}
+		if len(c.IDMembers) > 1 {
+			multipleMembers++
+		}
+
+		// После объединения меток разные метки Trello могут указывать на одну
+		cardLabels := make(map[string]bool, len(c.IDLabels))
+		for _, id := range c.IDLabels {
+			if labelID, ok := labelIDs[id]; ok && !cardLabels[labelID] {
+				cardLabels[labelID] = true
+				card.LabelIDs = append(card.LabelIDs, labelID)
+			}
+		}
+
+		cardChecklists := checklists[c.ID]
+		sort.SliceStable(cardChecklists, func(i, j int) bool { return cardChecklists[i].Pos < cardChecklists[j].Pos })
+		for _, cl := range cardChecklists {
+			title := strings.TrimSpace(cl.Name)
+			if title == "" {
+				title = "Чек-лист"
+			}
+			checklist := models.ExportedChecklist{Title: clip(title, 255), Items: []models.ExportedChecklistItem{}}
+
+			items := cl.CheckItems
+			sort.SliceStable(items, func(i, j int) bool { return items[i].Pos < items[j].Pos })
+			for _, it := range items {
+				text := strings.TrimSpace(it.Name)
+				if text == "" {
+					continue
+				}
+				checklist.Items = append(checklist.Items, models.ExportedChecklistItem{
+					Text:     clip(text, 500),
+					Done:     it.State == "complete",
+					Assignee: clip(members[it.IDMember], 255),
+					DueDate:  it.Due,
+				})
+			}
+			card.Checklists = append(card.Checklists, checklist)
+			report.Checklists++
+			report.ChecklistItems += len(checklist.Items)
+		}
+
+		// У комментариев Trello нет учетных записей на этом сервере,
+		// поэтому имя автора сохраняется в начале текста
+		cardComments := comments[c.ID]
+		sort.SliceStable(cardComments, func(i, j int) bool { return cardComments[i].Date.Before(cardComments[j].Date) })
+		for _, action := range cardComments {
+			body := action.Data.Text
+			if author := trelloMemberName(action.MemberCreator); author != "" {
+				body = author + ": " + body
+			}
+			card.Comments = append(card.Comments, models.ExportedComment{
+				ID:        action.ID,
+				Body:      body,
+				CreatedAt: action.Date,
+			})
+			report.Comments++
+		}
+
+		attachments += len(c.Attachments)
+		doc.Cards = append(doc.Cards, card)
+	}
+	report.Cards = len(doc.Cards)
+	report.Labels = len(doc.Labels)
+
+	for name := range assignees {
+		report.Assignees = append(report.Assignees, name)
+	}
+	sort.Strings(report.Assignees)
+
+	if multipleMembers > 0 {
+		warn("карточек с несколькими участниками, ответственным стал первый: " + strconv.Itoa(multipleMembers))
+	}
+	if attachments > 0 {
+		warn("не перенесено вложений: " + strconv.Itoa(attachments))
+	}
+	if truncated > 0 {
+		warn("обрезано слишком длинных названий: " + strconv.Itoa(truncated))
+	}
+
+	return doc, report
+}
+
+// trelloMemberName возвращает имя участника Trello для поля ответственного
+func trelloMemberName(member trelloMember) string {
+	if name := strings.TrimSpace(member.FullName); name != "" {
+		return name
+	}
+	return member.Username
+}
+
+// trelloCreatedAt восстанавливает время создания объекта Trello: первые 8 шестнадцатеричных
+// цифр его ID — unix-время создания. Для неразборчивого ID возвращается нулевое время.
+func trelloCreatedAt(id string) time.Time {
+	if len(id) < 8 {
+		return time.Time{}
+	}
+	seconds, err := strconv.ParseInt(id[:8], 16, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
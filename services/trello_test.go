@@ -0,0 +1,226 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// trelloFixture — сокращенный экспорт доски Trello: закрытые списки, метки с одинаковыми
+// названиями, чек-листы, комментарии и карточка без списка
+const trelloFixture = `{
+	"name": "  Релиз  ",
+	"lists": [
+		{"id": "5f5e1000aaaaaaaaaaaaaa01", "name": "To Do", "closed": false, "pos": 2},
+		{"id": "5f5e1000aaaaaaaaaaaaaa02", "name": "Done", "closed": false, "pos": 1},
+		{"id": "5f5e1000aaaaaaaaaaaaaa03", "name": "Old", "closed": true, "pos": 3},
+		{"id": "5f5e1000aaaaaaaaaaaaaa04", "name": "Empty", "closed": true, "pos": 4}
+	],
+	"labels": [
+		{"id": "lb1", "name": "bug", "color": "red"},
+		{"id": "lb2", "name": "bug", "color": "green_dark"},
+		{"id": "lb3", "name": "", "color": "sky_light"},
+		{"id": "lb4", "name": "", "color": null}
+	],
+	"members": [
+		{"id": "m1", "fullName": "Иван Петров", "username": "ivan"},
+		{"id": "m2", "fullName": "", "username": "anna"}
+	],
+	"cards": [
+		{
+			"id": "5f5e1000bbbbbbbbbbbbbb01", "name": "Починить вход", "desc": "Описание",
+			"idList": "5f5e1000aaaaaaaaaaaaaa01", "pos": 2, "due": "2026-02-01T10:00:00.000Z",
+			"dateLastActivity": "2026-01-10T08:00:00.000Z",
+			"idLabels": ["lb1", "lb2", "lb3"], "idMembers": ["m1", "m2"], "attachments": [{}]
+		},
+		{"id": "5f5e1000bbbbbbbbbbbbbb02", "name": " ", "idList": "5f5e1000aaaaaaaaaaaaaa01", "pos": 1},
+		{"id": "5f5e1000bbbbbbbbbbbbbb03", "name": "Готово", "idList": "5f5e1000aaaaaaaaaaaaaa02", "pos": 1},
+		{
+			"id": "5f5e1000bbbbbbbbbbbbbb04", "name": "Старое", "idList": "5f5e1000aaaaaaaaaaaaaa03", "pos": 1,
+			"dateLastActivity": "2025-05-01T00:00:00.000Z"
+		},
+		{
+			"id": "5f5e1000bbbbbbbbbbbbbb05", "name": "Закрытая", "idList": "5f5e1000aaaaaaaaaaaaaa01", "pos": 0,
+			"closed": true, "dateLastActivity": "2025-06-01T00:00:00.000Z", "idLabels": ["lb4"]
+		},
+		{"id": "5f5e1000bbbbbbbbbbbbbb06", "name": "Потерянная", "idList": "missing", "pos": 1}
+	],
+	"checklists": [
+		{
+			"id": "cl1", "name": "Проверка", "idCard": "5f5e1000bbbbbbbbbbbbbb01", "pos": 2,
+			"checkItems": [{"name": "Тесты", "state": "incomplete", "pos": 1}]
+		},
+		{
+			"id": "cl2", "name": "", "idCard": "5f5e1000bbbbbbbbbbbbbb01", "pos": 1,
+			"checkItems": [
+				{"name": "Ревью", "state": "incomplete", "pos": 2, "idMember": "m2"},
+				{"name": " ", "state": "incomplete", "pos": 3},
+				{"name": "Воспроизвести", "state": "complete", "pos": 1}
+			]
+		}
+	],
+	"actions": [
+		{
+			"id": "a1", "type": "commentCard", "date": "2026-01-05T12:00:00.000Z",
+			"memberCreator": {"id": "m1", "fullName": "Иван Петров"},
+			"data": {"text": "Позже", "card": {"id": "5f5e1000bbbbbbbbbbbbbb01"}}
+		},
+		{
+			"id": "a2", "type": "commentCard", "date": "2026-01-04T12:00:00.000Z",
+			"memberCreator": {"id": "m2", "username": "anna"},
+			"data": {"text": "Раньше", "card": {"id": "5f5e1000bbbbbbbbbbbbbb01"}}
+		},
+		{
+			"id": "a3", "type": "commentCard", "date": "2026-01-06T12:00:00.000Z",
+			"data": {"text": "  ", "card": {"id": "5f5e1000bbbbbbbbbbbbbb01"}}
+		},
+		{
+			"id": "a4", "type": "updateCard", "date": "2026-01-07T12:00:00.000Z",
+			"data": {"text": "не комментарий", "card": {"id": "5f5e1000bbbbbbbbbbbbbb01"}}
+		}
+	]
+}`
+
+func TestConvertTrelloBoard(t *testing.T) {
+	var trello trelloBoard
+	if err := json.Unmarshal([]byte(trelloFixture), &trello); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, report := convertTrelloBoard(&trello)
+
+	if err := validateBoardExport(doc); err != nil {
+		t.Fatalf("документ не прошел проверку: %v", err)
+	}
+
+	if doc.Board.Name != "Релиз" {
+		t.Errorf("имя доски %q", doc.Board.Name)
+	}
+
+	t.Run("колонки", func(t *testing.T) {
+		// Колонки идут по позиции списков, закрытый пустой список пропускается
+		var got []string
+		for _, column := range doc.Columns {
+			got = append(got, column.Name)
+		}
+		if want := []string{"Done", "To Do", "Old"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("колонки %q, want %q", got, want)
+		}
+	})
+
+	t.Run("метки", func(t *testing.T) {
+		want := map[string]string{"bug": "#eb5a46", "sky": "#00c2e0", "без цвета": trelloNoColor}
+		if len(doc.Labels) != len(want) {
+			t.Fatalf("меток %d, want %d: %+v", len(doc.Labels), len(want), doc.Labels)
+		}
+		for _, label := range doc.Labels {
+			if want[label.Name] != label.Color {
+				t.Errorf("метка %q цвета %q, want %q", label.Name, label.Color, want[label.Name])
+			}
+		}
+	})
+
+	cards := make(map[string]int, len(doc.Cards))
+	var order []string
+	for i, card := range doc.Cards {
+		cards[card.ID] = i
+		order = append(order, card.ID[len(card.ID)-2:])
+	}
+
+	t.Run("карточки", func(t *testing.T) {
+		// По колонкам, внутри колонки по позиции, закрытые — последними; карточка без списка пропущена
+		if want := []string{"03", "02", "01", "05", "04"}; !reflect.DeepEqual(order, want) {
+			t.Fatalf("порядок карточек %q, want %q", order, want)
+		}
+
+		untitled := doc.Cards[cards["5f5e1000bbbbbbbbbbbbbb02"]]
+		if untitled.Title != "Без названия" {
+			t.Errorf("заголовок пустой карточки %q", untitled.Title)
+		}
+
+		for id, archived := range map[string]bool{
+			"5f5e1000bbbbbbbbbbbbbb01": false,
+			"5f5e1000bbbbbbbbbbbbbb04": true,
+			"5f5e1000bbbbbbbbbbbbbb05": true,
+		} {
+			card := doc.Cards[cards[id]]
+			if (card.ArchivedAt != nil) != archived {
+				t.Errorf("карточка %s: archived_at = %v, want архивная %v", id, card.ArchivedAt, archived)
+			}
+			if archived && !card.ArchivedAt.Equal(card.UpdatedAt) {
+				t.Errorf("карточка %s архивирована %v, want %v", id, card.ArchivedAt, card.UpdatedAt)
+			}
+		}
+	})
+
+	t.Run("поля карточки", func(t *testing.T) {
+		card := doc.Cards[cards["5f5e1000bbbbbbbbbbbbbb01"]]
+
+		if card.Assignee != "Иван Петров" {
+			t.Errorf("ответственный %q", card.Assignee)
+		}
+		if card.Deadline == nil || !card.Deadline.Equal(time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)) {
+			t.Errorf("дедлайн %v", card.Deadline)
+		}
+		if want := time.Unix(0x5f5e1000, 0).UTC(); !card.CreatedAt.Equal(want) {
+			t.Errorf("создана %v, want %v", card.CreatedAt, want)
+		}
+		// lb2 объединена с lb1 и не дублируется
+		if want := []string{"lb1", "lb3"}; !reflect.DeepEqual(card.LabelIDs, want) {
+			t.Errorf("метки %q, want %q", card.LabelIDs, want)
+		}
+	})
+
+	t.Run("чек-листы", func(t *testing.T) {
+		checklists := doc.Cards[cards["5f5e1000bbbbbbbbbbbbbb01"]].Checklists
+		if len(checklists) != 2 {
+			t.Fatalf("чек-листов %d, want 2", len(checklists))
+		}
+		if checklists[0].Title != "Чек-лист" || checklists[1].Title != "Проверка" {
+			t.Fatalf("чек-листы %q и %q", checklists[0].Title, checklists[1].Title)
+		}
+
+		items := checklists[0].Items
+		if len(items) != 2 {
+			t.Fatalf("пунктов %d, want 2: %+v", len(items), items)
+		}
+		if items[0].Text != "Воспроизвести" || !items[0].Done {
+			t.Errorf("первый пункт %+v", items[0])
+		}
+		if items[1].Text != "Ревью" || items[1].Done || items[1].Assignee != "anna" {
+			t.Errorf("второй пункт %+v", items[1])
+		}
+	})
+
+	t.Run("комментарии", func(t *testing.T) {
+		var got []string
+		for _, comment := range doc.Cards[cards["5f5e1000bbbbbbbbbbbbbb01"]].Comments {
+			got = append(got, comment.Body)
+		}
+		if want := []string{"anna: Раньше", "Иван Петров: Позже"}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("комментарии %q, want %q", got, want)
+		}
+	})
+
+	t.Run("отчет", func(t *testing.T) {
+		if report.Board != "Релиз" || report.Columns != 3 || report.Cards != 5 || report.ArchivedCards != 2 ||
+			report.Labels != 3 || report.Checklists != 2 || report.ChecklistItems != 3 || report.Comments != 2 {
+			t.Errorf("отчет %+v", report)
+		}
+		if want := []string{"Иван Петров"}; !reflect.DeepEqual(report.Assignees, want) {
+			t.Errorf("ответственные %q, want %q", report.Assignees, want)
+		}
+
+		want := []string{
+			"объединено меток с повторяющимися названиями: 1",
+			"список «Old» в архиве Trello: его карточки перенесены в архив колонки с тем же названием",
+			"пропущено карточек без списка: 1",
+			"карточек с несколькими участниками, ответственным стал первый: 1",
+			"не перенесено вложений: 1",
+		}
+		if !reflect.DeepEqual(report.Warnings, want) {
+			t.Errorf("предупреждения %q, want %q", report.Warnings, want)
+		}
+	})
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"task-board/database"
+	"task-board/models"
+)
+
+type UserService struct {
+	db *gorm.DB
+}
+
+func NewUserService() *UserService {
+	return &UserService{
+		db: database.DB,
+	}
+}
+
+// Register создает учетную запись пользователя
+func (s *UserService) Register(req models.RegisterRequest) (*models.User, error) {
+	email := normalizeEmail(req.Email)
+
+	var count int64
+	if err := s.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return nil, errors.New("ошибка проверки пользователя")
+	}
+	if count > 0 {
+		return nil, errors.New("пользователь с таким email уже существует")
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("ошибка хеширования пароля")
+	}
+
+	user := &models.User{
+		ID:           generateID(),
+		Email:        email,
+		Name:         strings.TrimSpace(req.Name),
+		PasswordHash: string(passwordHash),
+	}
+
+	if err := s.db.Create(user).Error; err != nil {
+		return nil, errors.New("ошибка создания пользователя")
+	}
+
+	return user, nil
+}
+
+// Authenticate проверяет email и пароль пользователя
+func (s *UserService) Authenticate(email, password string) (*models.User, error) {
+	var user models.User
+
+	if err := s.db.First(&user, "email = ?", normalizeEmail(email)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("неверный email или пароль")
+		}
+		return nil, errors.New("ошибка получения пользователя")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, errors.New("неверный email или пароль")
+	}
+
+	return &user, nil
+}
+
+// GetUser получает пользователя по ID
+func (s *UserService) GetUser(userID string) (*models.User, error) {
+	var user models.User
+
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("пользователь не найден")
+		}
+		return nil, errors.New("ошибка получения пользователя")
+	}
+
+	return &user, nil
+}
+
+// ListBoards возвращает доски, участником которых является пользователь
+func (s *UserService) ListBoards(userID string) ([]models.BoardSummary, error) {
+	boards := []models.BoardSummary{}
+
+	if err := s.db.Model(&models.Board{}).
+		Select("boards.id, boards.name").
+		Joins("JOIN board_members ON board_members.board_id = boards.id").
+		Where("board_members.user_id = ?", userID).
+		Order("boards.created_at ASC").
+		Scan(&boards).Error; err != nil {
+		return nil, errors.New("ошибка получения досок пользователя")
+	}
+
+	return boards, nil
+}
+
+// BoardIDs возвращает идентификаторы досок пользователя для включения в токен
+func (s *UserService) BoardIDs(userID string) ([]string, error) {
+	boards, err := s.ListBoards(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(boards))
+	for _, board := range boards {
+		ids = append(ids, board.ID)
+	}
+
+	return ids, nil
+}
+
+// IsMember проверяет, что пользователь является участником доски
+func (s *UserService) IsMember(boardID, userID string) (bool, error) {
+	var count int64
+
+	if err := s.db.Model(&models.BoardMember{}).
+		Where("board_id = ? AND user_id = ?", boardID, userID).
+		Count(&count).Error; err != nil {
+		return false, errors.New("ошибка проверки участника доски")
+	}
+
+	return count > 0, nil
+}
+
+// ListMembers возвращает участников доски
+func (s *UserService) ListMembers(boardID string) ([]models.BoardMember, error) {
+	members := []models.BoardMember{}
+
+	if err := s.db.Preload("User").Where("board_id = ?", boardID).
+		Order("created_at ASC").Find(&members).Error; err != nil {
+		return nil, errors.New("ошибка получения участников доски")
+	}
+
+	return members, nil
+}
+
+// AddMember добавляет пользователя с указанным email в участники доски
+func (s *UserService) AddMember(boardID string, req models.AddMemberRequest) (*models.BoardMember, error) {
+	var user models.User
+	if err := s.db.First(&user, "email = ?", normalizeEmail(req.Email)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("пользователь не найден")
+		}
+		return nil, errors.New("ошибка получения пользователя")
+	}
+
+	isMember, err := s.IsMember(boardID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if isMember {
+		return nil, errors.New("пользователь уже является участником доски")
+	}
+
+	role := req.Role
+	if role == "" {
+		role = models.RoleEditor
+	}
+	if !role.Valid() {
+		return nil, errors.New("неизвестная роль")
+	}
+
+	member := &models.BoardMember{
+		BoardID: boardID,
+		UserID:  user.ID,
+		Role:    role,
+	}
+
+	if err := s.db.Create(member).Error; err != nil {
+		return nil, errors.New("ошибка добавления участника")
+	}
+
+	member.User = user
+	return member, nil
+}
+
+// UpdateMemberRole меняет роль участника доски
+func (s *UserService) UpdateMemberRole(boardID, userID string, req models.UpdateMemberRequest) (*models.BoardMember, error) {
+	if !req.Role.Valid() {
+		return nil, errors.New("неизвестная роль")
+	}
+
+	var member models.BoardMember
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockMember(tx, boardID, userID, &member); err != nil {
+			return err
+		}
+
+		if member.Role == models.RoleOwner && req.Role != models.RoleOwner {
+			if err := ensureAnotherOwner(tx, boardID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&member).Update("role", req.Role).Error; err != nil {
+			return errors.New("ошибка обновления роли участника")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Preload("User").First(&member, "board_id = ? AND user_id = ?", boardID, userID).Error; err != nil {
+		return nil, errors.New("ошибка перезагрузки участника")
+	}
+
+	return &member, nil
+}
+
+// RemoveMember удаляет пользователя из участников доски
+func (s *UserService) RemoveMember(boardID, userID string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var member models.BoardMember
+		if err := lockMember(tx, boardID, userID, &member); err != nil {
+			return err
+		}
+
+		// Доска не должна остаться без владельца
+		if member.Role == models.RoleOwner {
+			if err := ensureAnotherOwner(tx, boardID); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(&member).Error; err != nil {
+			return errors.New("ошибка удаления участника")
+		}
+
+		return nil
+	})
+}
+
+// BoardRole возвращает роль пользователя на доске. Для гостевого входа
+// используется гостевая роль доски, если гостевой доступ включен.
+func (s *UserService) BoardRole(boardID, userID string) (models.Role, error) {
+	if userID == "" {
+		var board models.Board
+		if err := s.db.Select("guest_access", "guest_role", "password_hash").First(&board, "id = ?", boardID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", nil
+			}
+			return "", errors.New("ошибка проверки доступа к доске")
+		}
+
+		if !board.GuestAccess || board.PasswordHash == "" {
+			return "", nil
+		}
+		return board.GuestRole, nil
+	}
+
+	var member models.BoardMember
+	if err := s.db.Select("role").First(&member, "board_id = ? AND user_id = ?", boardID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", errors.New("ошибка проверки доступа к доске")
+	}
+
+	return member.Role, nil
+}
+
+// ClaimBoard делает зарегистрированного пользователя владельцем доски без
+// владельца. Право на доску подтверждается ее паролем: так владельца получают
+// доски, созданные гостем, и старые доски без участников.
+func (s *UserService) ClaimBoard(boardID, userID, password string) (*models.BoardMember, error) {
+	var member models.BoardMember
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Блокируем доску, чтобы два пользователя не заняли ее одновременно
+		var board models.Board
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "password_hash").First(&board, "id = ?", boardID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("доска не найдена")
+			}
+			return errors.New("ошибка получения доски")
+		}
+
+		var owners int64
+		if err := tx.Model(&models.BoardMember{}).
+			Where("board_id = ? AND role = ?", boardID, models.RoleOwner).
+			Count(&owners).Error; err != nil {
+			return errors.New("ошибка проверки владельцев доски")
+		}
+		if owners > 0 {
+			return errors.New("у доски уже есть владелец")
+		}
+
+		if board.PasswordHash == "" {
+			return errors.New("у доски нет пароля")
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(board.PasswordHash), []byte(password)); err != nil {
+			return errors.New("неверный пароль")
+		}
+
+		err := tx.First(&member, "board_id = ? AND user_id = ?", boardID, userID).Error
+		switch {
+		case err == nil:
+			member.Role = models.RoleOwner
+			if err := tx.Model(&member).Update("role", models.RoleOwner).Error; err != nil {
+				return errors.New("ошибка назначения владельца")
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			member = models.BoardMember{
+				BoardID: boardID,
+				UserID:  userID,
+				Role:    models.RoleOwner,
+			}
+			if err := tx.Create(&member).Error; err != nil {
+				return errors.New("ошибка назначения владельца")
+			}
+		default:
+			return errors.New("ошибка получения участника")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// lockMember загружает участника доски с блокировкой строки
+func lockMember(tx *gorm.DB, boardID, userID string, member *models.BoardMember) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(member, "board_id = ? AND user_id = ?", boardID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("участник не найден")
+		}
+		return errors.New("ошибка получения участника")
+	}
+	return nil
+}
+
+// ensureAnotherOwner проверяет, что у доски останется хотя бы один владелец
+func ensureAnotherOwner(tx *gorm.DB, boardID string) error {
+	// Блокируем строки владельцев, чтобы два владельца не разжаловали друг друга одновременно
+	var owners []models.BoardMember
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("board_id = ? AND role = ?", boardID, models.RoleOwner).
+		Find(&owners).Error; err != nil {
+		return errors.New("ошибка проверки владельцев доски")
+	}
+
+	if len(owners) <= 1 {
+		return errors.New("нельзя убрать единственного владельца доски")
+	}
+	return nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"task-board/database"
+	"task-board/events"
+	"task-board/models"
+)
+
+type ViewService struct {
+	publisher
+	db *gorm.DB
+}
+
+func NewViewService(broker events.Broker) *ViewService {
+	return &ViewService{
+		publisher: publisher{broker: broker},
+		db:        database.DB,
+	}
+}
+
+// List возвращает сохраненные представления доски
+func (s *ViewService) List(boardID string) ([]models.SavedView, error) {
+	views := []models.SavedView{}
+
+	if err := s.db.Where("board_id = ?", boardID).Order("name ASC").Find(&views).Error; err != nil {
+		return nil, errors.New("ошибка получения представлений")
+	}
+
+	return views, nil
+}
+
+// Create сохраняет фильтр доски под именем
+func (s *ViewService) Create(actor Actor, boardID string, req models.SavedViewRequest) (*models.SavedView, error) {
+	name, query, err := validateSavedView(req)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &models.SavedView{
+		ID:        generateID(),
+		BoardID:   boardID,
+		Name:      name,
+		Query:     query,
+		CreatedBy: actor.UserID,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(view).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("представление с таким названием уже есть")
+			}
+			return errors.New("ошибка создания представления")
+		}
+
+		changes := models.FieldChanges{
+			"view_id": {After: view.ID},
+			"name":    {After: name},
+			"query":   {After: query},
+		}
+		return recordActivity(tx, actor, events.SavedViewCreated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.SavedViewCreated, actor, boardID, view)
+
+	return view, nil
+}
+
+// Update меняет название и фильтр представления
+func (s *ViewService) Update(actor Actor, boardID, viewID string, req models.SavedViewRequest) (*models.SavedView, error) {
+	name, query, err := validateSavedView(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var view models.SavedView
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := loadSavedView(tx, boardID, viewID, &view); err != nil {
+			return err
+		}
+
+		changes := models.FieldChanges{"view_id": {After: viewID}}
+		if view.Name != name {
+			changes["name"] = models.FieldChange{Before: view.Name, After: name}
+		}
+		if view.Query != query {
+			changes["query"] = models.FieldChange{Before: view.Query, After: query}
+		}
+
+		if err := tx.Model(&view).Updates(map[string]interface{}{"name": name, "query": query}).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return errors.New("представление с таким названием уже есть")
+			}
+			return errors.New("ошибка обновления представления")
+		}
+		view.Name = name
+		view.Query = query
+
+		return recordActivity(tx, actor, events.SavedViewUpdated, boardID, "", "", changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.SavedViewUpdated, actor, boardID, view)
+
+	return &view, nil
+}
+
+// Delete удаляет представление; ссылки на него перестают работать
+func (s *ViewService) Delete(actor Actor, boardID, viewID string) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var view models.SavedView
+		if err := loadSavedView(tx, boardID, viewID, &view); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&view).Error; err != nil {
+			return errors.New("ошибка удаления представления")
+		}
+
+		changes := models.FieldChanges{
+			"view_id": {Before: view.ID},
+			"name":    {Before: view.Name},
+			"query":   {Before: view.Query},
+		}
+		return recordActivity(tx, actor, events.SavedViewDeleted, boardID, "", "", changes)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.SavedViewDeleted, actor, boardID, deletedPayload{ID: viewID})
+
+	return nil
+}
+
+// validateSavedView проверяет название представления и разбирает его фильтр,
+// чтобы не сохранить фильтр, который доска потом не примет
+func validateSavedView(req models.SavedViewRequest) (string, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return "", "", errors.New("название представления обязательно")
+	}
+	query := strings.TrimSpace(req.Query)
+	if _, err := parseBoardFilter(query); err != nil {
+		return "", "", err
+	}
+	return name, query, nil
+}
+
+// loadSavedView загружает сохраненное представление доски
+func loadSavedView(tx *gorm.DB, boardID, viewID string, view *models.SavedView) error {
+	if err := tx.First(view, "id = ? AND board_id = ?", viewID, boardID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("представление не найдено")
+		}
+		return errors.New("ошибка получения представления")
+	}
+	return nil
+}
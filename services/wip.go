@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"task-board/events"
+	"task-board/models"
+)
+
+// ErrWIPLimitExceeded возвращается, если операция превысит жесткий WIP-лимит колонки
+var ErrWIPLimitExceeded = errors.New("превышен WIP-лимит колонки")
+
+// SetWIPLimit задает WIP-лимит колонки. Limit = 0 снимает лимит; жесткий лимит
+// запрещает операции, которые его превысят, мягкий только помечает их.
+func (s *BoardService) SetWIPLimit(actor Actor, boardID, columnID string, req models.WIPLimitRequest) (*models.Column, error) {
+	if req.Limit < 0 {
+		return nil, errors.New("WIP-лимит не может быть отрицательным")
+	}
+
+	var column models.Column
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND board_id = ?", columnID, boardID).First(&column).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("колонка не найдена")
+			}
+			return errors.New("ошибка получения колонки")
+		}
+		before := columnSnapshot(&column)
+
+		err := updateVersioned(tx, &column, req.Version, map[string]interface{}{
+			"wip_limit":      req.Limit,
+			"wip_limit_hard": req.Hard && req.Limit > 0,
+		})
+		if errors.Is(err, errVersionMismatch) {
+			return err
+		}
+		if err != nil {
+			return errors.New("ошибка обновления колонки")
+		}
+
+		if err := tx.First(&column, "id = ?", columnID).Error; err != nil {
+			return errors.New("ошибка перезагрузки колонки")
+		}
+
+		return recordActivity(tx, actor, events.ColumnUpdated, boardID, "", columnID,
+			diffSnapshots(before, columnSnapshot(&column)))
+	})
+	if errors.Is(err, errVersionMismatch) {
+		return nil, s.columnConflict(columnID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(events.ColumnUpdated, actor, boardID, column)
+
+	return &column, nil
+}
+
+// checkWIPLimit проверяет, не превысит ли добавление карточек WIP-лимит колонки;
+// adding — число добавляемых карточек.
+// Для жесткого лимита возвращает ErrWIPLimitExceeded, для мягкого — exceeded = true.
+// Строка колонки блокируется до конца транзакции, чтобы параллельные операции
+// не превысили лимит вместе.
+func checkWIPLimit(tx *gorm.DB, columnID string, adding int) (exceeded bool, err error) {
+	if adding == 0 {
+		return false, nil
+	}
+
+	var column models.Column
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "wip_limit", "wip_limit_hard").
+		First(&column, "id = ?", columnID).Error; err != nil {
+		return false, errors.New("ошибка получения колонки")
+	}
+	if column.WIPLimit == 0 {
+		return false, nil
+	}
+
+	var count int64
+	if err := rankedCards(tx, columnID).Count(&count).Error; err != nil {
+		return false, errors.New("ошибка подсчета карточек колонки")
+	}
+	if int(count)+adding <= column.WIPLimit {
+		return false, nil
+	}
+
+	if column.WIPLimitHard {
+		return false, ErrWIPLimitExceeded
+	}
+	return true, nil
+}
+
+// countColumnCards возвращает число карточек на доске по колонкам
+func countColumnCards(tx *gorm.DB, boardID string) (map[string]int, error) {
+	type columnCount struct {
+		ColumnID string
+		Count    int
+	}
+	var rows []columnCount
+	if err := tx.Model(&models.Card{}).Select("column_id, COUNT(*) AS count").
+		Where("board_id = ? AND archived_at IS NULL", boardID).Group("column_id").Scan(&rows).Error; err != nil {
+		return nil, errors.New("ошибка подсчета карточек колонок")
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.ColumnID] = row.Count
+	}
+	return counts, nil
+}
+
+// markOverWIPLimit отмечает колонки доски, в которых карточек больше WIP-лимита.
+// counts — число карточек по колонкам, если карточки доски отобраны фильтром;
+// при nil считаются загруженные карточки.
+func markOverWIPLimit(board *models.Board, counts map[string]int) {
+	for i := range board.Columns {
+		column := &board.Columns[i]
+		column.CardCount = len(column.Cards)
+		if counts != nil {
+			column.CardCount = counts[column.ID]
+		}
+		column.OverWIPLimit = column.WIPLimit > 0 && column.CardCount > column.WIPLimit
+	}
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unsignedPayload — хеш тела, который S3 принимает вместо SHA-256 потокового содержимого
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Config содержит параметры S3-совместимого хранилища (AWS S3, MinIO и т.п.)
+type S3Config struct {
+	Endpoint  string // например, http://localhost:9000 или https://s3.eu-central-1.amazonaws.com
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle включает адреса вида endpoint/bucket/key вместо bucket.endpoint/key (нужно для MinIO)
+	PathStyle bool
+}
+
+// GetS3ConfigFromEnv получает конфигурацию S3 из переменных окружения
+func GetS3ConfigFromEnv() S3Config {
+	pathStyle := true
+	if value, err := strconv.ParseBool(os.Getenv("S3_PATH_STYLE")); err == nil {
+		pathStyle = value
+	}
+
+	region := os.Getenv("S3_REGION")
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    region,
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PathStyle: pathStyle,
+	}
+}
+
+// S3Storage хранит файлы в бакете S3-совместимого хранилища.
+// Запросы подписываются AWS Signature Version 4 без сторонних SDK.
+type S3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Storage создает клиент S3-совместимого хранилища
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, fmt.Errorf("для хранилища S3 нужно задать S3_ENDPOINT и S3_BUCKET")
+	}
+
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("неверный адрес S3: %q", config.Endpoint)
+	}
+
+	return &S3Storage{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Put загружает объект
+func (s *S3Storage) Put(key string, r io.Reader, size int64, contentType string) error {
+	req, err := s.newRequest(http.MethodPut, key, r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+// Open скачивает объект
+func (s *S3Storage) Open(key string) (io.ReadCloser, error) {
+	req, err := s.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	default:
+		defer resp.Body.Close()
+		return nil, s3Error(resp)
+	}
+}
+
+// Delete удаляет объект
+func (s *S3Storage) Delete(key string) error {
+	req, err := s.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK &&
+		resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+// newRequest строит запрос к объекту key с учетом стиля адресации
+func (s *S3Storage) newRequest(method, key string, body io.Reader) (*http.Request, error) {
+	host := s.endpoint.Host
+	path := strings.TrimRight(s.endpoint.EscapedPath(), "/")
+	if s.config.PathStyle {
+		path += "/" + uriEncode(s.config.Bucket, true)
+	} else {
+		host = s.config.Bucket + "." + host
+	}
+	path += "/" + uriEncode(key, false)
+
+	return http.NewRequest(method, s.endpoint.Scheme+"://"+host+path, body)
+}
+
+// do подписывает и выполняет запрос
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	s.sign(req, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign добавляет к запросу подпись AWS Signature Version 4
+func (s *S3Storage) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + unsignedPayload + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"", // параметров запроса нет
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	scope, signature := s.signature(canonicalRequest, now)
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// signature возвращает область учетных данных и подпись канонического запроса
+func (s *S3Storage) signature(canonicalRequest string, now time.Time) (string, string) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return scope, hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// s3Error формирует ошибку из ответа S3
+func s3Error(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("ошибка хранилища S3: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+// uriEncode кодирует строку по правилам SigV4: не кодируются только A-Z, a-z, 0-9, "-", ".", "_", "~"
+// и, если encodeSlash ложно, "/"
+func uriEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,23 @@
+// Package storage хранит содержимое вложений карточек.
+//
+// Содержимое адресуется ключом вида "<board_id>/<attachment_id>"; метаданные
+// вложений хранятся в БД, а сами файлы — в одной из реализаций Storage.
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotFound возвращается, когда объекта с таким ключом нет в хранилище
+var ErrNotFound = errors.New("файл не найден в хранилище")
+
+// Storage — хранилище содержимого вложений
+type Storage interface {
+	// Put сохраняет size байт из r под ключом key
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Open открывает содержимое объекта для чтения
+	Open(key string) (io.ReadCloser, error)
+	// Delete удаляет объект; отсутствие объекта ошибкой не считается
+	Delete(key string) error
+}